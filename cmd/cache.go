@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"ccstatus/internal/api"
+	"ccstatus/internal/config"
+	"ccstatus/internal/statusline"
+	"ccstatus/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+// cacheJSONFlag selects machine-readable output for the cache subcommands
+var cacheJSONFlag bool
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect, refresh or clear the usage cache",
+	Long: `Inspect, refresh or clear the usage cache the statusline renders from.
+
+Entries are kept per account; show and refresh operate on the account
+currently signed in to Claude Code. Use --json for script-friendly output.`,
+	Args: cobra.NoArgs,
+}
+
+var cacheShowCmd = &cobra.Command{
+	Use:           "show",
+	Short:         "Show the cached usage for the current account",
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	RunE:          runCacheShow,
+}
+
+var cacheRefreshCmd = &cobra.Command{
+	Use:           "refresh",
+	Short:         "Fetch usage now, ignoring the cache TTL and any backoff",
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	RunE:          runCacheRefresh,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:           "clear",
+	Short:         "Delete the cache file for every account",
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	RunE:          runCacheClear,
+}
+
+func init() {
+	cacheCmd.PersistentFlags().BoolVar(&cacheJSONFlag, "json", false, "Print JSON instead of text")
+
+	for _, sub := range []*cobra.Command{cacheShowCmd, cacheRefreshCmd, cacheClearCmd} {
+		addConfigDirFlag(sub)
+		cacheCmd.AddCommand(sub)
+	}
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// cacheStatusJSON is the --json form of a cache entry
+type cacheStatusJSON struct {
+	Path                string                 `json:"path"`
+	Account             string                 `json:"account"`
+	Fresh               bool                   `json:"fresh"`
+	FetchedAt           *time.Time             `json:"fetched_at,omitempty"`
+	TTLSeconds          int64                  `json:"ttl_seconds"`
+	TTLRemainingSeconds int64                  `json:"ttl_remaining_seconds"`
+	Usage               *api.UsageResponse     `json:"usage,omitempty"`
+	LastError           *statusline.FetchError `json:"last_error,omitempty"`
+	Error               string                 `json:"error,omitempty"`
+}
+
+func newCacheStatusJSON(status *statusline.CacheStatus) cacheStatusJSON {
+	out := cacheStatusJSON{
+		Path:                status.Path,
+		Account:             status.Account,
+		Fresh:               status.Fresh(),
+		TTLSeconds:          int64(status.TTL.Seconds()),
+		TTLRemainingSeconds: int64(status.Remaining().Seconds()),
+		Usage:               status.Usage,
+		LastError:           status.LastError,
+	}
+	if !status.FetchedAt.IsZero() {
+		out.FetchedAt = &status.FetchedAt
+	}
+	return out
+}
+
+func runCacheShow(cmd *cobra.Command, args []string) error {
+	cfg, _ := config.LoadCCStatusConfig()
+
+	status, err := statusline.GetCacheStatus(cfg, statusline.CurrentAccount(cfg))
+	if err != nil {
+		return cacheFailure("Cannot read cache", err)
+	}
+
+	if cacheJSONFlag {
+		return printJSON(newCacheStatusJSON(status))
+	}
+
+	ui.CompactTitle("ccstatus cache")
+	fmt.Println()
+	printCacheStatus(status)
+	fmt.Println()
+	return nil
+}
+
+func runCacheRefresh(cmd *cobra.Command, args []string) error {
+	cfg, _ := config.LoadCCStatusConfig()
+
+	token, err := statusline.GetAccessToken(cfg)
+	if err != nil {
+		return cacheFailure("No OAuth token", err)
+	}
+
+	_, fetchErr := statusline.RefreshUsage(cfg, token, claudeCodeVersion())
+
+	status, err := statusline.GetCacheStatus(cfg, statusline.CurrentAccount(cfg))
+	if err != nil {
+		return cacheFailure("Cannot read cache", err)
+	}
+
+	if cacheJSONFlag {
+		out := newCacheStatusJSON(status)
+		if fetchErr != nil {
+			out.Error = fetchErr.Error()
+		}
+		if err := printJSON(out); err != nil {
+			return err
+		}
+		return fetchErr
+	}
+
+	ui.CompactTitle("ccstatus cache refresh")
+	fmt.Println()
+	if fetchErr != nil {
+		ui.StatusError("Refresh failed", describeAPIError(fetchErr))
+	} else {
+		ui.StatusOK("Refreshed", "")
+	}
+	fmt.Println()
+	printCacheStatus(status)
+	fmt.Println()
+	return fetchErr
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	if err := statusline.ClearCache(); err != nil {
+		return cacheFailure("Cannot clear cache", err)
+	}
+
+	if cacheJSONFlag {
+		return printJSON(map[string]bool{"cleared": true})
+	}
+
+	fmt.Println()
+	ui.SuccessMessage("Cache cleared", "The next statusline render fetches fresh usage.")
+	fmt.Println()
+	return nil
+}
+
+// printCacheStatus prints a cache entry as key-value lines
+func printCacheStatus(status *statusline.CacheStatus) {
+	ui.PrintPath("File", status.Path)
+	account := status.Account
+	if account == "" {
+		account = "unknown (not signed in)"
+	}
+	ui.PrintKeyValue("Account", account)
+
+	if status.Usage == nil {
+		ui.PrintKeyValue("Fetched", "never")
+	} else {
+		ui.PrintKeyValue("Fetched", fmt.Sprintf("%s ago (%s)",
+			formatDuration(time.Since(status.FetchedAt)), status.FetchedAt.Local().Format(time.DateTime)))
+
+		if status.Fresh() {
+			ui.PrintKeyValue("TTL", fmt.Sprintf("%s left of %s", formatDuration(status.Remaining()), formatDuration(status.TTL)))
+		} else {
+			ui.PrintKeyValue("TTL", fmt.Sprintf("expired (%s)", formatDuration(status.TTL)))
+		}
+
+		ui.PrintKeyValue("Session", fmt.Sprintf("%d%%", int(status.Usage.FiveHour.Utilization)))
+		ui.PrintKeyValue("Week", fmt.Sprintf("%d%%", int(status.Usage.SevenDay.Utilization)))
+	}
+
+	if status.LastError == nil {
+		ui.PrintKeyValue("Last error", "none")
+		return
+	}
+	lastErr := status.LastError
+	message := fmt.Sprintf("%s %s ago: %s", statusline.ErrorLabel(lastErr.Kind), formatDuration(time.Since(lastErr.At)), lastErr.Message)
+	if wait := time.Until(lastErr.RetryAt); wait > 0 {
+		message += fmt.Sprintf(" (next attempt in %s)", formatDuration(wait))
+	}
+	ui.PrintKeyValue("Last error", message)
+}
+
+// cacheFailure reports err in the selected output format and returns it so
+// the command exits non-zero
+func cacheFailure(title string, err error) error {
+	if cacheJSONFlag {
+		_ = printJSON(map[string]string{"error": fmt.Sprintf("%s: %v", title, err)})
+	} else {
+		ui.ErrorMessage(title, err.Error())
+	}
+	return err
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -123,12 +123,66 @@ func initialModel() (configModel, error) {
 			description: "Show when usage limits reset",
 			enabled:     cfg.ShowResetTimes,
 		},
+		{
+			key:         "pace",
+			label:       "Pace Colors",
+			description: "Color usage by pace against the time elapsed in its window",
+			enabled:     cfg.PaceMode,
+		},
+		{
+			key:         "projection",
+			label:       "Session Projection",
+			description: "Show when the session limit will be hit at the recent burn rate",
+			enabled:     cfg.ShowProjection,
+		},
+		{
+			key:         "sparkline",
+			label:       "Session Sparkline",
+			description: "Show recent session usage as a sparkline",
+			enabled:     cfg.ShowSparkline,
+		},
+		{
+			key:         "sparkline-ascii",
+			label:       "ASCII Sparkline",
+			description: "Draw the sparkline with ASCII characters instead of block glyphs",
+			enabled:     cfg.SparklineASCII,
+		},
+		{
+			key:         "session-delta",
+			label:       "Session Consumption",
+			description: "Show how much usage this Claude Code session has consumed",
+			enabled:     cfg.ShowSessionDelta,
+		},
+		{
+			key:         "budget",
+			label:       "Daily Budget",
+			description: "Show weekly usage left per remaining working day",
+			enabled:     cfg.ShowDailyBudget,
+		},
 		{
 			key:         "git",
 			label:       "Git Branch",
 			description: "Show current git branch name",
 			enabled:     cfg.ShowGitBranch,
 		},
+		{
+			key:         "age",
+			label:       "Data Age",
+			description: "Show how long ago stale usage was fetched",
+			enabled:     cfg.ShowDataAge,
+		},
+		{
+			key:         "background",
+			label:       "Background Refresh",
+			description: "Render from cache instantly and refresh usage in the background",
+			enabled:     cfg.BackgroundRefresh,
+		},
+		{
+			key:         "adaptive",
+			label:       "Adaptive Refresh",
+			description: "Refresh more often near the limit or a reset, less often when idle",
+			enabled:     cfg.AdaptiveTTL,
+		},
 	}
 
 	return configModel{
@@ -195,10 +249,45 @@ func (m configModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m configModel) checkForChanges() bool {
-	return m.options[0].enabled != m.originalCfg.ShowSessionUsage ||
-		m.options[1].enabled != m.originalCfg.ShowWeeklyUsage ||
-		m.options[2].enabled != m.originalCfg.ShowResetTimes ||
-		m.options[3].enabled != m.originalCfg.ShowGitBranch
+	for _, opt := range m.options {
+		if field := toggleField(m.originalCfg, opt.key); field != nil && *field != opt.enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// toggleField returns the config field controlled by the option with key
+func toggleField(cfg *config.CCStatusConfig, key string) *bool {
+	switch key {
+	case "session":
+		return &cfg.ShowSessionUsage
+	case "weekly":
+		return &cfg.ShowWeeklyUsage
+	case "reset":
+		return &cfg.ShowResetTimes
+	case "pace":
+		return &cfg.PaceMode
+	case "projection":
+		return &cfg.ShowProjection
+	case "sparkline":
+		return &cfg.ShowSparkline
+	case "sparkline-ascii":
+		return &cfg.SparklineASCII
+	case "session-delta":
+		return &cfg.ShowSessionDelta
+	case "budget":
+		return &cfg.ShowDailyBudget
+	case "git":
+		return &cfg.ShowGitBranch
+	case "age":
+		return &cfg.ShowDataAge
+	case "background":
+		return &cfg.BackgroundRefresh
+	case "adaptive":
+		return &cfg.AdaptiveTTL
+	}
+	return nil
 }
 
 func (m configModel) View() string {
@@ -296,12 +385,14 @@ func (m configModel) View() string {
 }
 
 func (m configModel) getConfig() *config.CCStatusConfig {
-	return &config.CCStatusConfig{
-		ShowSessionUsage: m.options[0].enabled,
-		ShowWeeklyUsage:  m.options[1].enabled,
-		ShowResetTimes:   m.options[2].enabled,
-		ShowGitBranch:    m.options[3].enabled,
+	// Copy the loaded config so settings not shown in the UI are preserved
+	cfg := *m.originalCfg
+	for _, opt := range m.options {
+		if field := toggleField(&cfg, opt.key); field != nil {
+			*field = opt.enabled
+		}
 	}
+	return &cfg
 }
 
 func runConfig(cmd *cobra.Command, args []string) error {
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"ccstatus/internal/daemon"
+	"ccstatus/internal/statusline"
+	"ccstatus/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Poll usage in the background and serve it to the statusline",
+	Long: `Run a long-lived process that holds the OAuth token, polls the usage
+endpoint on the cache TTL schedule and serves the latest usage over a Unix
+socket in the Claude config directory.
+
+While the daemon is running the statusline asks the socket instead of
+reading credentials and calling the API itself. When it is not running
+the statusline falls back to fetching directly.`,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	RunE:          runDaemon,
+}
+
+func init() {
+	daemonCmd.Flags().String(statusline.ClaudeVersionFlag, "", "Claude Code version for the User-Agent (detected when empty)")
+	addConfigDirFlag(daemonCmd)
+	rootCmd.AddCommand(daemonCmd)
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	claudeVersion, _ := cmd.Flags().GetString(statusline.ClaudeVersionFlag)
+	if claudeVersion == "" {
+		claudeVersion = claudeCodeVersion()
+	}
+
+	path, err := daemon.GetSocketPath()
+	if err != nil {
+		ui.ErrorMessage("Cannot start daemon", err.Error())
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "ccstatus daemon serving usage on %s\n", path)
+
+	if err := statusline.RunDaemon(ctx, claudeVersion); err != nil {
+		if errors.Is(err, daemon.ErrRunning) {
+			ui.WarningMessage("Daemon already running", "Another ccstatus daemon is serving "+path)
+		} else {
+			ui.ErrorMessage("Daemon stopped", err.Error())
+		}
+		return err
+	}
+	return nil
+}
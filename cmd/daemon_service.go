@@ -0,0 +1,390 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"ccstatus/internal/config"
+	"ccstatus/internal/daemon"
+	"ccstatus/internal/service"
+	"ccstatus/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var daemonInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Start the daemon on login",
+	Long: `Install writes a service definition that starts the ccstatus daemon on login:
+systemd user units with socket activation on Linux, a launchd agent on macOS.
+
+Existing files are backed up before they are replaced, and you will be
+asked to confirm before any changes are made.`,
+	Args: cobra.NoArgs,
+	RunE: runDaemonInstall,
+}
+
+var daemonUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Stop the daemon and remove its service definition",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonUninstall,
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the daemon is installed and serving usage",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStatus,
+}
+
+func init() {
+	for _, sub := range []*cobra.Command{daemonInstallCmd, daemonUninstallCmd, daemonStatusCmd} {
+		addConfigDirFlag(sub)
+		daemonCmd.AddCommand(sub)
+	}
+}
+
+// serviceOptions describes the daemon of the active profile on this machine
+func serviceOptions() (service.Options, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return service.Options{}, fmt.Errorf("cannot determine home directory: %w", err)
+	}
+
+	socketPath, err := daemon.GetSocketPath()
+	if err != nil {
+		return service.Options{}, err
+	}
+
+	var configDir string
+	if config.CustomConfigDir() != "" {
+		if configDir, err = config.GetConfigDir(); err != nil {
+			return service.Options{}, err
+		}
+	}
+
+	exe, err := daemonExecutable()
+	if err != nil {
+		return service.Options{}, err
+	}
+
+	return service.Options{
+		GOOS:       runtime.GOOS,
+		Home:       home,
+		ConfigHome: os.Getenv("XDG_CONFIG_HOME"),
+		Executable: exe,
+		ConfigDir:  configDir,
+		SocketPath: socketPath,
+	}, nil
+}
+
+// daemonExecutable returns the path the service should run. The PATH entry
+// is preferred when it is this binary, since package managers keep that
+// path stable across upgrades while the resolved file moves.
+func daemonExecutable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("cannot locate ccstatus binary: %w", err)
+	}
+
+	if inPath, err := exec.LookPath("ccstatus"); err == nil {
+		if abs, err := filepath.Abs(inPath); err == nil && sameFile(abs, exe) {
+			return abs, nil
+		}
+	}
+	return exe, nil
+}
+
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+func runDaemonInstall(cmd *cobra.Command, args []string) error {
+	ui.CompactTitle("ccstatus daemon install")
+
+	opts, err := serviceOptions()
+	if err != nil {
+		ui.ErrorMessage("Failed to prepare service", err.Error())
+		return nil
+	}
+
+	files, err := service.Files(opts)
+	if err != nil {
+		ui.ErrorMessage("Unsupported platform", err.Error())
+		return nil
+	}
+
+	// Show current state
+	fmt.Println()
+	ui.Bold.Println("  Service files")
+	ui.Divider()
+	fmt.Println()
+
+	var pending []service.File
+	var backups []string
+	for _, f := range files {
+		ui.PrintPath("Location", f.Path)
+		changed, existing := service.Changed(f)
+		switch {
+		case !changed:
+			ui.StatusOK("File", "Up to date")
+		case existing:
+			ui.StatusWarning("File", "Differs from the generated definition")
+			backups = append(backups, f.Path)
+			pending = append(pending, f)
+		default:
+			ui.StatusInfo("File", "Will be created")
+			pending = append(pending, f)
+		}
+	}
+
+	replacing := len(backups) > 0
+	var replace [][]string
+	if replacing {
+		replace = service.ReplaceCommands(opts)
+	}
+	activate := service.ActivateCommands(opts, replacing)
+
+	if len(pending) == 0 {
+		ui.SuccessMessage("Already installed!", "The service definition is up to date.")
+		fmt.Println()
+		ui.Dim.Println("  To (re)start it, run:")
+		for _, c := range activate {
+			ui.Dim.Println("    " + strings.Join(c, " "))
+		}
+		fmt.Println()
+		return nil
+	}
+
+	// Show what will change
+	fmt.Println()
+	ui.Bold.Println("  Changes to be made")
+	ui.Divider()
+	fmt.Println()
+
+	stepNum := 1
+	for _, path := range backups {
+		ui.Step(stepNum, "Create a backup of "+path)
+		stepNum++
+	}
+	for _, f := range pending {
+		ui.Step(stepNum, "Write "+f.Path+":")
+		fmt.Println()
+		ui.CodeBlock(strings.TrimRight(strings.ReplaceAll(f.Content, "\t", "  "), "\n"))
+		fmt.Println()
+		stepNum++
+	}
+	for _, c := range append(replace, activate...) {
+		ui.Step(stepNum, "Run "+ui.InfoBold.Sprint(strings.Join(c, " ")))
+		stepNum++
+	}
+
+	if !ui.Confirm("Apply these changes?") {
+		fmt.Println()
+		ui.WarningMessage("Installation cancelled", "No changes were made.")
+		fmt.Println()
+		return nil
+	}
+
+	fmt.Println()
+	for _, path := range backups {
+		backupPath, err := service.Backup(path)
+		if err != nil {
+			ui.ErrorMessage("Failed to create backup", err.Error())
+			return nil
+		}
+		ui.StatusOK("Backup created", backupPath)
+	}
+
+	for _, f := range pending {
+		if err := service.Write(f); err != nil {
+			ui.ErrorMessage("Failed to write service file", err.Error())
+			return nil
+		}
+		ui.StatusOK("Written", f.Path)
+	}
+
+	s := ui.NewProgressSpinner("Starting the daemon...")
+	s.Start()
+	// The old job may not be loaded, in which case unloading it fails
+	for _, c := range replace {
+		_ = runServiceCommand(c)
+	}
+	for _, c := range activate {
+		if err := runServiceCommand(c); err != nil {
+			s.Stop()
+			ui.ErrorMessage("Failed to start the daemon", err.Error())
+			return nil
+		}
+	}
+	s.Stop()
+	ui.StatusOK("Daemon started", "")
+
+	ui.SuccessMessage("Installation complete!", "")
+	fmt.Println()
+	ui.InfoBox(
+		"The ccstatus daemon now starts on login.",
+		"",
+		"Check it with: ccstatus daemon status",
+	)
+	fmt.Println()
+
+	return nil
+}
+
+func runDaemonUninstall(cmd *cobra.Command, args []string) error {
+	ui.CompactTitle("ccstatus daemon uninstall")
+
+	opts, err := serviceOptions()
+	if err != nil {
+		ui.ErrorMessage("Failed to prepare service", err.Error())
+		return nil
+	}
+
+	files, err := service.Files(opts)
+	if err != nil {
+		ui.ErrorMessage("Unsupported platform", err.Error())
+		return nil
+	}
+
+	var installed []string
+	for _, f := range files {
+		if _, existing := service.Changed(f); existing {
+			installed = append(installed, f.Path)
+		}
+	}
+
+	if len(installed) == 0 {
+		ui.SuccessMessage("Not installed", "No daemon service files were found.")
+		fmt.Println()
+		return nil
+	}
+
+	fmt.Println()
+	ui.Bold.Println("  Changes to be made")
+	ui.Divider()
+	fmt.Println()
+
+	stepNum := 1
+	for _, c := range service.DeactivateCommands(opts) {
+		ui.Step(stepNum, "Run "+ui.InfoBold.Sprint(strings.Join(c, " ")))
+		stepNum++
+	}
+	for _, path := range installed {
+		ui.Step(stepNum, "Back up and remove "+path)
+		stepNum++
+	}
+
+	if !ui.Confirm("Remove the daemon service?") {
+		fmt.Println()
+		ui.WarningMessage("Uninstall cancelled", "No changes were made.")
+		fmt.Println()
+		return nil
+	}
+
+	fmt.Println()
+	for _, c := range service.DeactivateCommands(opts) {
+		// The service may already be stopped or unloaded
+		if err := runServiceCommand(c); err != nil {
+			ui.StatusWarning("Stop", err.Error())
+		}
+	}
+
+	for _, path := range installed {
+		backupPath, err := service.Backup(path)
+		if err != nil {
+			ui.ErrorMessage("Failed to create backup", err.Error())
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			ui.ErrorMessage("Failed to remove service file", err.Error())
+			return nil
+		}
+		ui.StatusOK("Removed", path+" (backup: "+filepath.Base(backupPath)+")")
+	}
+
+	for _, c := range service.ReloadCommands(opts) {
+		_ = runServiceCommand(c)
+	}
+
+	ui.SuccessMessage("Uninstall complete!", "The statusline will fetch usage directly again.")
+	fmt.Println()
+
+	return nil
+}
+
+func runDaemonStatus(cmd *cobra.Command, args []string) error {
+	ui.CompactTitle("ccstatus daemon status")
+	fmt.Println()
+
+	opts, err := serviceOptions()
+	if err != nil {
+		ui.ErrorMessage("Failed to prepare service", err.Error())
+		return nil
+	}
+
+	if files, err := service.Files(opts); err == nil {
+		for _, f := range files {
+			changed, existing := service.Changed(f)
+			switch {
+			case !existing:
+				ui.StatusInfo(filepath.Base(f.Path), "Not installed")
+			case changed:
+				ui.StatusWarning(filepath.Base(f.Path), "Installed, differs from the generated definition")
+			default:
+				ui.StatusOK(filepath.Base(f.Path), "Installed")
+			}
+		}
+
+		if c := service.StatusCommand(opts); c != nil {
+			output, err := exec.Command(c[0], c[1:]...).CombinedOutput()
+			state := strings.Join(strings.Fields(firstLine(string(output))), " ")
+			if state == "" {
+				state = "unknown"
+			}
+			if err != nil {
+				ui.StatusInfo("Service manager", state)
+			} else {
+				ui.StatusOK("Service manager", state)
+			}
+		}
+	}
+
+	snapshot, err := daemon.Query(opts.SocketPath)
+	switch {
+	case err == nil && snapshot.Usage != nil:
+		ui.StatusOK("Socket", fmt.Sprintf("Serving usage fetched %s ago", formatDuration(time.Since(snapshot.FetchedAt))))
+	case err == nil:
+		ui.StatusWarning("Socket", "Running, no usage yet")
+	case err == daemon.ErrNoSnapshot:
+		ui.StatusWarning("Socket", "Running, waiting for the first poll")
+	default:
+		ui.StatusInfo("Socket", "Not running - the statusline fetches usage directly")
+	}
+	ui.PrintPath("Socket path", opts.SocketPath)
+	fmt.Println()
+
+	return nil
+}
+
+// runServiceCommand runs a service manager command, including its output in the error
+func runServiceCommand(args []string) error {
+	output, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
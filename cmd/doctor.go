@@ -1,12 +1,14 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
-	"os"
 	"os/exec"
+	"strings"
 	"time"
 
+	"ccstatus/internal/api"
 	"ccstatus/internal/config"
 	"ccstatus/internal/statusline"
 	"ccstatus/internal/ui"
@@ -23,8 +25,9 @@ Checks performed:
   - Claude Code configuration exists
   - ccstatus is properly configured in settings
   - ccstatus binary is in PATH
-  - OAuth token is available in Keychain
-  - Anthropic API endpoint is reachable`,
+  - OAuth token is available from a credential provider and not expired
+  - Anthropic API endpoint is reachable
+  - Last statusline fetch succeeded`,
 	RunE: runDoctor,
 }
 
@@ -53,6 +56,7 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		{"Binary in PATH", checkBinaryInPath},
 		{"OAuth token", checkOAuthToken},
 		{"Anthropic API", checkAPIEndpoint},
+		{"Last statusline fetch", checkLastFetchError},
 	}
 
 	checks := make([]checkResult, 0, len(checkFuncs))
@@ -204,78 +208,173 @@ func checkOAuthToken() checkResult {
 		name: "OAuth token",
 	}
 
-	token, err := statusline.GetAccessToken()
+	cfg, _ := config.LoadCCStatusConfig()
+	creds, provider, err := statusline.LoadCredentials(cfg)
 	if err != nil {
 		result.ok = false
 		result.message = fmt.Sprintf("Cannot retrieve: %v", err)
 		return result
 	}
 
-	if token == "" {
-		result.ok = false
-		result.message = "Empty token - sign in to Claude Code"
+	source := fmt.Sprintf("%s (from %s)", maskToken(creds.ClaudeAiOauth.AccessToken), provider.Name())
+
+	expiresAt := creds.ExpiresAt()
+	if expiresAt.IsZero() {
+		result.ok = true
+		result.message = source
 		return result
 	}
 
-	// Mask token for display
-	maskedToken := token[:8] + "..." + token[len(token)-4:]
-	result.ok = true
-	result.message = maskedToken
+	remaining := time.Until(expiresAt)
+	if remaining > 0 {
+		result.ok = true
+		result.message = fmt.Sprintf("%s, expires in %s", source, formatDuration(remaining))
+		return result
+	}
+
+	if creds.CanRefresh() && statusline.CanSaveCredentials(provider) {
+		result.ok = true
+		result.message = fmt.Sprintf("%s, expired %s ago (will be refreshed)", source, formatDuration(-remaining))
+		if refreshErr, ok := statusline.LastRefreshError(); ok {
+			result.ok = false
+			result.message = fmt.Sprintf("%s, expired %s ago; refresh failed %s ago: %s",
+				source, formatDuration(-remaining), formatDuration(time.Since(refreshErr.At)), refreshErr.Message)
+		}
+		return result
+	}
+
+	result.ok = false
+	result.message = fmt.Sprintf("Expired %s ago - run 'claude' once to refresh", formatDuration(-remaining))
 	return result
 }
 
+// formatDuration renders a duration as a short human-readable string (e.g. "2h 14m")
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+
+	d = d.Round(time.Minute)
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
+
+// maskToken hides all but the edges of a token for display
+func maskToken(token string) string {
+	if len(token) <= 12 {
+		return "****"
+	}
+	return token[:8] + "..." + token[len(token)-4:]
+}
+
 func checkAPIEndpoint() checkResult {
 	result := checkResult{
 		name: "Anthropic API",
 	}
 
 	// First check if we have a token
-	token, err := statusline.GetAccessToken()
+	cfg, _ := config.LoadCCStatusConfig()
+	token, err := statusline.GetAccessToken(cfg)
 	if err != nil || token == "" {
 		result.ok = false
 		result.message = "Skipped (no token)"
 		return result
 	}
 
-	// Try to reach the API
-	client := &http.Client{Timeout: 5 * time.Second}
-	req, err := http.NewRequest("GET", "https://api.anthropic.com/api/oauth/usage", nil)
+	// Send the same request the statusline sends, through the same client
+	client, err := api.NewFromConfig(cfg, claudeCodeVersion())
 	if err != nil {
 		result.ok = false
-		result.message = fmt.Sprintf("Request error: %v", err)
+		result.message = fmt.Sprintf("Invalid network settings: %v", err)
 		return result
 	}
 
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("anthropic-beta", "oauth-2025-04-20")
-	req.Header.Set("User-Agent", "claude-code")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		if os.IsTimeout(err) {
-			result.ok = false
-			result.message = "Request timed out"
-			return result
-		}
+	if _, err := client.FetchUsage(context.Background(), token); err != nil {
 		result.ok = false
-		result.message = fmt.Sprintf("Connection failed: %v", err)
+		result.message = describeAPIError(err)
 		return result
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == 401 {
-		result.ok = false
-		result.message = "Token rejected (401) - run 'claude' once to refresh"
-		return result
+	result.ok = true
+	result.message = "Reachable"
+	if base := client.BaseURL(); base != api.DefaultBaseURL {
+		result.message = "Reachable at " + base
 	}
+	return result
+}
 
-	if resp.StatusCode != 200 {
-		result.ok = false
-		result.message = fmt.Sprintf("HTTP %d", resp.StatusCode)
+func checkLastFetchError() checkResult {
+	result := checkResult{
+		name: "Last statusline fetch",
+	}
+
+	cfg, _ := config.LoadCCStatusConfig()
+	fetchErr, ok := statusline.LastFetchError(statusline.CurrentAccount(cfg))
+	if !ok {
+		result.ok = true
+		result.message = "No errors recorded"
 		return result
 	}
 
-	result.ok = true
-	result.message = "Reachable"
+	result.ok = false
+	result.message = fmt.Sprintf("%s %s ago: %s",
+		statusline.ErrorLabel(fetchErr.Kind), formatDuration(time.Since(fetchErr.At)), fetchErr.Message)
+	if fetchErr.Failures > 1 {
+		result.message += fmt.Sprintf(" (%d in a row)", fetchErr.Failures)
+	}
+	if wait := time.Until(fetchErr.RetryAt); wait > 0 {
+		result.message += fmt.Sprintf(", next attempt in %s", formatDuration(wait))
+	}
 	return result
 }
+
+// describeAPIError turns a classified API error into a doctor message
+func describeAPIError(err error) string {
+	var apiErr *api.Error
+	if !errors.As(err, &apiErr) {
+		return fmt.Sprintf("Request failed: %v", err)
+	}
+
+	switch apiErr.Kind {
+	case api.KindAuth:
+		return fmt.Sprintf("Token rejected (%d) - run 'claude' once to refresh", apiErr.StatusCode)
+	case api.KindRateLimited:
+		return "Rate limited (429) - try again in a few minutes"
+	case api.KindServer:
+		return fmt.Sprintf("Server error (HTTP %d)", apiErr.StatusCode)
+	case api.KindOffline:
+		return fmt.Sprintf("Connection failed: %v", apiErr.Err)
+	case api.KindTimeout:
+		return "Request timed out"
+	case api.KindMalformed:
+		return "Unexpected response format"
+	case api.KindAPI:
+		return fmt.Sprintf("API error: %s", apiErr.Message)
+	default:
+		return apiErr.Error()
+	}
+}
+
+// claudeCodeVersion asks the installed claude CLI for its version so doctor
+// sends the same User-Agent Claude Code passes to the statusline
+func claudeCodeVersion() string {
+	output, err := exec.Command("claude", "--version").Output()
+	if err != nil {
+		return ""
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     string
+	}{
+		{duration: 20 * time.Second, want: "20s"},
+		{duration: 45 * time.Minute, want: "45m"},
+		{duration: 2*time.Hour + 14*time.Minute, want: "2h 14m"},
+		{duration: 50 * time.Hour, want: "2d 2h"},
+	}
+
+	for _, tt := range tests {
+		if got := formatDuration(tt.duration); got != tt.want {
+			t.Fatalf("formatDuration(%v): expected %q, got %q", tt.duration, tt.want, got)
+		}
+	}
+}
+
+func TestMaskTokenHandlesShortTokens(t *testing.T) {
+	if got := maskToken("short"); got != "****" {
+		t.Fatalf("expected short token to be fully masked, got %q", got)
+	}
+	if got := maskToken("sk-ant-oat01-abcdefgh"); got != "sk-ant-o...efgh" {
+		t.Fatalf("unexpected mask %q", got)
+	}
+}
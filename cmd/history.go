@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"ccstatus/internal/api"
+	"ccstatus/internal/config"
+	"ccstatus/internal/history"
+	"ccstatus/internal/statusline"
+	"ccstatus/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+// History windows selectable with --window
+const (
+	windowSession = "session"
+	windowWeek    = "week"
+)
+
+// History output formats selectable with --format
+const (
+	formatTable = "table"
+	formatCSV   = "csv"
+	formatJSON  = "json"
+)
+
+var (
+	historySince       string
+	historyUntil       string
+	historyWindow      string
+	historyFormat      string
+	historyAllAccounts bool
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List or export recorded usage snapshots",
+	Long: `List the usage snapshots recorded on every successful fetch.
+
+--since and --until accept a date (2026-03-01), a date and time
+(2026-03-01 14:00 or RFC 3339), or a duration before now (90m, 24h, 7d).
+A date alone includes the whole day.
+
+Use --format csv or --format json to export the data for spreadsheets
+and notebooks.`,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	RunE:          runHistory,
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historySince, "since", "", "Only snapshots at or after this time")
+	historyCmd.Flags().StringVar(&historyUntil, "until", "", "Only snapshots at or before this time")
+	historyCmd.Flags().StringVar(&historyWindow, "window", "", "Only show one window: session or week")
+	historyCmd.Flags().StringVar(&historyFormat, "format", formatTable, "Output format: table, csv or json")
+	historyCmd.Flags().BoolVar(&historyAllAccounts, "all-accounts", false, "Include snapshots of every account, not just the current one")
+	addConfigDirFlag(historyCmd)
+	rootCmd.AddCommand(historyCmd)
+}
+
+// historyJSON is the --format json form of a record
+type historyJSON struct {
+	Time     time.Time        `json:"time"`
+	Account  string           `json:"account"`
+	FiveHour *api.UsageWindow `json:"five_hour,omitempty"`
+	SevenDay *api.UsageWindow `json:"seven_day,omitempty"`
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	now := time.Now()
+
+	filter := history.Filter{}
+	var err error
+	if filter.Since, err = parseTimeFlag(historySince, now, false); err != nil {
+		return historyFailure(fmt.Errorf("invalid --since: %w", err))
+	}
+	if filter.Until, err = parseTimeFlag(historyUntil, now, true); err != nil {
+		return historyFailure(fmt.Errorf("invalid --until: %w", err))
+	}
+	if historyWindow != "" && historyWindow != windowSession && historyWindow != windowWeek {
+		return historyFailure(fmt.Errorf("invalid --window %q: use session or week", historyWindow))
+	}
+	if historyFormat != formatTable && historyFormat != formatCSV && historyFormat != formatJSON {
+		return historyFailure(fmt.Errorf("invalid --format %q: use table, csv or json", historyFormat))
+	}
+
+	cfg, _ := config.LoadCCStatusConfig()
+	if !historyAllAccounts {
+		filter.Account = statusline.CurrentAccount(cfg)
+	}
+
+	store, err := history.Open(cfg)
+	if err != nil {
+		return historyFailure(err)
+	}
+	records, skipped, err := store.Records()
+	if err != nil {
+		return historyFailure(err)
+	}
+	records = history.Select(records, filter)
+
+	switch historyFormat {
+	case formatCSV:
+		return writeHistoryCSV(records)
+	case formatJSON:
+		return writeHistoryJSON(records)
+	}
+
+	ui.CompactTitle("ccstatus history")
+	fmt.Println()
+
+	if len(records) == 0 {
+		ui.Dim.Println("  No usage snapshots match.")
+		ui.PrintPath("History file", store.Path())
+		fmt.Println()
+		return nil
+	}
+
+	headers, rows := historyTable(records)
+	ui.Table(headers, rows)
+	fmt.Println()
+	ui.Dim.Printf("  %d snapshots", len(records))
+	if skipped > 0 {
+		ui.Dim.Printf(", %d unreadable lines skipped", skipped)
+	}
+	fmt.Println()
+	fmt.Println()
+	return nil
+}
+
+// showWindow reports whether the --window filter includes window
+func showWindow(window string) bool {
+	return historyWindow == "" || historyWindow == window
+}
+
+// historyTable formats records for ui.Table in local time
+func historyTable(records []history.Record) ([]string, [][]string) {
+	headers := []string{"Time"}
+	if historyAllAccounts {
+		headers = append(headers, "Account")
+	}
+	if showWindow(windowSession) {
+		headers = append(headers, "Session", "Resets")
+	}
+	if showWindow(windowWeek) {
+		headers = append(headers, "Week", "Resets")
+	}
+
+	rows := make([][]string, 0, len(records))
+	for _, rec := range records {
+		row := []string{rec.Time.Local().Format("2006-01-02 15:04")}
+		if historyAllAccounts {
+			row = append(row, rec.Account)
+		}
+		if showWindow(windowSession) {
+			row = append(row, fmt.Sprintf("%d%%", int(rec.FiveHour.Utilization)), formatLocalTime(rec.FiveHour.ResetsAt))
+		}
+		if showWindow(windowWeek) {
+			row = append(row, fmt.Sprintf("%d%%", int(rec.SevenDay.Utilization)), formatLocalTime(rec.SevenDay.ResetsAt))
+		}
+		rows = append(rows, row)
+	}
+	return headers, rows
+}
+
+func writeHistoryCSV(records []history.Record) error {
+	header := []string{"time", "account"}
+	if showWindow(windowSession) {
+		header = append(header, "five_hour_utilization", "five_hour_resets_at")
+	}
+	if showWindow(windowWeek) {
+		header = append(header, "seven_day_utilization", "seven_day_resets_at")
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, rec := range records {
+		row := []string{rec.Time.Format(time.RFC3339), rec.Account}
+		if showWindow(windowSession) {
+			row = append(row, strconv.FormatFloat(rec.FiveHour.Utilization, 'f', -1, 64), rec.FiveHour.ResetsAt)
+		}
+		if showWindow(windowWeek) {
+			row = append(row, strconv.FormatFloat(rec.SevenDay.Utilization, 'f', -1, 64), rec.SevenDay.ResetsAt)
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func writeHistoryJSON(records []history.Record) error {
+	out := make([]historyJSON, 0, len(records))
+	for _, rec := range records {
+		entry := historyJSON{Time: rec.Time, Account: rec.Account}
+		if showWindow(windowSession) {
+			entry.FiveHour = &rec.FiveHour
+		}
+		if showWindow(windowWeek) {
+			entry.SevenDay = &rec.SevenDay
+		}
+		out = append(out, entry)
+	}
+	return printJSON(out)
+}
+
+// parseTimeFlag parses a --since/--until value: a date, a date and time,
+// or a duration before now. Empty means unbounded. A date alone covers the
+// whole day, so with endOfDay it means the last instant of that day.
+func parseTimeFlag(value string, now time.Time, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if endOfDay {
+			return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+		}
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("%q is not a date, time or duration", value)
+}
+
+// formatLocalTime formats an API timestamp in local time for tables
+func formatLocalTime(iso string) string {
+	t, err := time.Parse(time.RFC3339, iso)
+	if err != nil {
+		return "--"
+	}
+	return t.Local().Format("Jan 2 15:04")
+}
+
+// historyFailure reports err and returns it so the command exits non-zero
+func historyFailure(err error) error {
+	if historyFormat == formatTable {
+		ui.ErrorMessage("Cannot show history", err.Error())
+	} else {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+	return err
+}
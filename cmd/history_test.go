@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeFlag(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		until bool
+		want  time.Time
+	}{
+		{value: "", want: time.Time{}},
+		{value: "90m", want: now.Add(-90 * time.Minute)},
+		{value: "7d", want: now.AddDate(0, 0, -7)},
+		{value: "2026-03-01T08:30:00Z", want: time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)},
+		{value: "2026-03-01", want: time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)},
+		{value: "2026-03-01", until: true, want: time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local).Add(-time.Nanosecond)},
+		{value: "2026-03-01 14:00", until: true, want: time.Date(2026, 3, 1, 14, 0, 0, 0, time.Local)},
+		{value: "2026-03-01 14:00", want: time.Date(2026, 3, 1, 14, 0, 0, 0, time.Local)},
+	}
+
+	for _, tt := range tests {
+		got, err := parseTimeFlag(tt.value, now, tt.until)
+		if err != nil {
+			t.Fatalf("parseTimeFlag(%q): %v", tt.value, err)
+		}
+		if !got.Equal(tt.want) {
+			t.Fatalf("parseTimeFlag(%q): expected %v, got %v", tt.value, tt.want, got)
+		}
+	}
+}
+
+func TestParseTimeFlagRejectsGarbage(t *testing.T) {
+	for _, value := range []string{"yesterday", "7x", "-3d", "2026-13-01"} {
+		if _, err := parseTimeFlag(value, time.Now(), false); err == nil {
+			t.Fatalf("expected %q to be rejected", value)
+		}
+	}
+}
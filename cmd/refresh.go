@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"ccstatus/internal/statusline"
+
+	"github.com/spf13/cobra"
+)
+
+// refreshCmd is spawned by the statusline in background refresh mode
+var refreshCmd = &cobra.Command{
+	Use:    statusline.RefreshCommand,
+	Short:  "Refresh the usage cache in the background",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		claudeVersion, _ := cmd.Flags().GetString(statusline.ClaudeVersionFlag)
+		// Failures are recorded in the cache for the next render and doctor
+		_ = statusline.RunRefresher(claudeVersion)
+		return nil
+	},
+}
+
+func init() {
+	refreshCmd.Flags().String(statusline.ClaudeVersionFlag, "", "Claude Code version for the User-Agent")
+	rootCmd.AddCommand(refreshCmd)
+}
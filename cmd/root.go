@@ -4,6 +4,7 @@ package cmd
 import (
 	"os"
 
+	"ccstatus/internal/config"
 	"ccstatus/internal/statusline"
 
 	"github.com/spf13/cobra"
@@ -32,6 +33,21 @@ Use subcommands for installation and diagnostics.`,
 	SilenceUsage: true,
 }
 
+// configDirFlag holds the --config-dir value shared by profile-aware commands
+var configDirFlag string
+
+// addConfigDirFlag registers --config-dir on cmd. The flag selects the Claude
+// Code profile to operate on, taking precedence over CLAUDE_CONFIG_DIR.
+func addConfigDirFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&configDirFlag, "config-dir", "",
+		"Claude config directory (defaults to $CLAUDE_CONFIG_DIR or ~/.claude)")
+	cmd.PreRun = func(cmd *cobra.Command, args []string) {
+		if configDirFlag != "" {
+			config.SetConfigDir(configDirFlag)
+		}
+	}
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -54,4 +70,8 @@ func init() {
 	rootCmd.AddCommand(uninstallCmd)
 	rootCmd.AddCommand(doctorCmd)
 	rootCmd.AddCommand(versionCmd)
+
+	addConfigDirFlag(installCmd)
+	addConfigDirFlag(uninstallCmd)
+	addConfigDirFlag(doctorCmd)
 }
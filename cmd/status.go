@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ccstatus/internal/config"
+	"ccstatus/internal/statusline"
+	"ccstatus/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show a usage report with pace, projection and daily budget",
+	Long: `Show the current account's usage in detail: both windows with their
+reset times and pace, when the session limit will be hit at the recent
+burn rate, and how much of the weekly limit is left per working day.
+
+Cached usage is used while it is fresh; otherwise it is fetched first.`,
+	Args:          cobra.NoArgs,
+	SilenceErrors: true,
+	RunE:          runStatus,
+}
+
+func init() {
+	addConfigDirFlag(statusCmd)
+	rootCmd.AddCommand(statusCmd)
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	cfg, _ := config.LoadCCStatusConfig()
+	account := statusline.CurrentAccount(cfg)
+
+	status, err := statusline.GetCacheStatus(cfg, account)
+	if err != nil {
+		ui.ErrorMessage("Cannot read cache", err.Error())
+		return err
+	}
+
+	var fetchErr error
+	if !status.Fresh() {
+		var token string
+		if token, fetchErr = statusline.GetAccessToken(cfg); fetchErr == nil {
+			if _, fetchErr = statusline.FetchUsage(cfg, token, claudeCodeVersion()); fetchErr == nil {
+				if status, err = statusline.GetCacheStatus(cfg, account); err != nil {
+					ui.ErrorMessage("Cannot read cache", err.Error())
+					return err
+				}
+			}
+		}
+	}
+
+	ui.CompactTitle("ccstatus status")
+	fmt.Println()
+
+	if status.Usage == nil {
+		if fetchErr == nil {
+			fetchErr = fmt.Errorf("no usage has been fetched yet")
+		}
+		ui.ErrorMessage("No usage data", describeAPIError(fetchErr))
+		return fetchErr
+	}
+
+	now := time.Now()
+	report := statusline.NewReport(cfg, account, status.Usage, now)
+
+	ui.Bold.Println("  Session (5 hours)")
+	ui.Divider()
+	printWindowReport(report.Session, now, "3:04pm")
+	switch {
+	case report.Projection == nil:
+		ui.PrintKeyValue("Projection", "not enough recent history")
+	case report.Projection.Safe():
+		ui.PrintKeyValue("Projection", "safe until reset")
+	default:
+		ui.PrintKeyValue("Projection", fmt.Sprintf("100%% at %s (%.0f%%/hour)",
+			report.Projection.LimitAt.Local().Format("3:04pm"), report.Projection.Rate))
+	}
+	fmt.Println()
+
+	ui.Bold.Println("  Week (7 days)")
+	ui.Divider()
+	printWindowReport(report.Week, now, "Mon Jan 2 3:04pm")
+	if report.HasDailyBudget {
+		ui.PrintKeyValue("Daily budget", fmt.Sprintf("%s over %.1f working days",
+			statusline.FormatDailyBudget(report.DailyBudget), report.WorkingDaysLeft))
+	} else {
+		ui.PrintKeyValue("Daily budget", "no working days left before the reset")
+	}
+	if len(report.NonWorkingDays) > 0 {
+		names := make([]string, len(report.NonWorkingDays))
+		for i, d := range report.NonWorkingDays {
+			names[i] = d.String()[:3]
+		}
+		ui.PrintKeyValue("Non-working days", strings.Join(names, ", "))
+	}
+	if len(report.InvalidDays) > 0 {
+		ui.StatusWarning("non_working_days", "Ignoring unknown days: "+strings.Join(report.InvalidDays, ", "))
+	}
+	fmt.Println()
+
+	age := formatDuration(now.Sub(status.FetchedAt)) + " ago"
+	if fetchErr != nil {
+		ui.StatusWarning("Data", "Fetched "+age+"; refresh failed: "+describeAPIError(fetchErr))
+	} else {
+		ui.StatusOK("Data", "Fetched "+age)
+	}
+	fmt.Println()
+
+	return nil
+}
+
+// pacePhrases describe each pace in the report
+var pacePhrases = map[string]string{
+	"ahead":  "ahead of pace",
+	"on":     "on pace",
+	"behind": "behind pace",
+}
+
+// printWindowReport prints a window's usage, pace and reset time; layout
+// formats the reset time
+func printWindowReport(window statusline.WindowReport, now time.Time, layout string) {
+	value := fmt.Sprintf("%d%%", int(window.Utilization))
+	if window.Estimated {
+		value = "~" + value + " (estimated, window reset since the last fetch)"
+	} else if phrase, ok := pacePhrases[window.Pace]; ok {
+		value += " (" + phrase + ")"
+	}
+	ui.PrintKeyValue("Usage", value)
+
+	if window.ResetsAt.IsZero() {
+		ui.PrintKeyValue("Resets", "unknown")
+		return
+	}
+	ui.PrintKeyValue("Resets", fmt.Sprintf("%s (in %s)",
+		window.ResetsAt.Local().Format(layout), formatDuration(window.ResetsAt.Sub(now))))
+}
@@ -0,0 +1,196 @@
+// Package api is the HTTP client for the Anthropic OAuth endpoints ccstatus uses.
+// It owns request headers, timeouts, response decoding and error classification
+// so the statusline and doctor send exactly the same request.
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"ccstatus/internal/config"
+)
+
+const (
+	// DefaultBaseURL is the Anthropic API origin
+	DefaultBaseURL = "https://api.anthropic.com"
+	// UsagePath is the OAuth usage endpoint
+	UsagePath = "/api/oauth/usage"
+	// OAuthBeta is the anthropic-beta flag required by OAuth endpoints
+	OAuthBeta = "oauth-2025-04-20"
+	// DefaultTimeout bounds every request made by the client
+	DefaultTimeout = 10 * time.Second
+)
+
+// UsageResponse represents the API response from Anthropic
+type UsageResponse struct {
+	FiveHour UsageWindow `json:"five_hour"`
+	SevenDay UsageWindow `json:"seven_day"`
+	Error    *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// UsageWindow is the utilization of one rate-limit window
+type UsageWindow struct {
+	Utilization float64 `json:"utilization"`
+	ResetsAt    string  `json:"resets_at"`
+}
+
+// TokenResponse is the OAuth token endpoint response
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// Options configures a Client
+type Options struct {
+	// BaseURL is the API origin; defaults to DefaultBaseURL
+	BaseURL string
+	// Transport performs the requests; defaults to http.DefaultTransport
+	Transport http.RoundTripper
+	// Timeout bounds each request; defaults to DefaultTimeout
+	Timeout time.Duration
+	// UserAgent is sent with every request; defaults to UserAgent("")
+	UserAgent string
+}
+
+// Client talks to the Anthropic OAuth endpoints
+type Client struct {
+	baseURL   string
+	http      *http.Client
+	userAgent string
+}
+
+// New creates a client from explicit options
+func New(opts Options) *Client {
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
+	}
+	if opts.Transport == nil {
+		opts.Transport = http.DefaultTransport
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = UserAgent("")
+	}
+
+	return &Client{
+		baseURL:   opts.BaseURL,
+		http:      &http.Client{Timeout: opts.Timeout, Transport: opts.Transport},
+		userAgent: opts.UserAgent,
+	}
+}
+
+// NewFromConfig creates a client using the base URL, proxy and CA bundle from
+// cfg. claudeCodeVersion is the version reported by Claude Code, if known.
+func NewFromConfig(cfg *config.CCStatusConfig, claudeCodeVersion string) (*Client, error) {
+	transport, err := NewTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(Options{
+		BaseURL:   BaseURL(cfg),
+		Transport: transport,
+		UserAgent: UserAgent(claudeCodeVersion),
+	}), nil
+}
+
+// BaseURL returns the API origin the client sends requests to
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// UserAgent returns the User-Agent Claude Code itself would send
+func UserAgent(claudeCodeVersion string) string {
+	if claudeCodeVersion == "" {
+		return "claude-code"
+	}
+	return "claude-code/" + claudeCodeVersion
+}
+
+// FetchUsage retrieves the current usage for the account owning token
+func (c *Client) FetchUsage(ctx context.Context, token string) (*UsageResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+UsagePath, nil)
+	if err != nil {
+		return nil, &Error{Kind: KindUnexpected, Err: err}
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("anthropic-beta", OAuthBeta)
+
+	var usage UsageResponse
+	if err := c.do(req, &usage); err != nil {
+		return nil, err
+	}
+
+	if usage.Error != nil {
+		return nil, &Error{Kind: KindAPI, Message: usage.Error.Message}
+	}
+
+	return &usage, nil
+}
+
+// RefreshToken exchanges refreshToken for a new access token at endpoint
+func (c *Client) RefreshToken(ctx context.Context, endpoint, clientID, refreshToken string) (*TokenResponse, error) {
+	payload, err := json.Marshal(map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": refreshToken,
+		"client_id":     clientID,
+	})
+	if err != nil {
+		return nil, &Error{Kind: KindUnexpected, Err: err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, &Error{Kind: KindUnexpected, Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var token TokenResponse
+	if err := c.do(req, &token); err != nil {
+		return nil, err
+	}
+
+	if token.AccessToken == "" {
+		return nil, &Error{Kind: KindMalformed, Message: "token endpoint returned no access token"}
+	}
+
+	return &token, nil
+}
+
+// do sends req and decodes a 200 JSON response into out
+func (c *Client) do(req *http.Request, out any) error {
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return transportError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return transportError(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return &Error{Kind: KindMalformed, Err: fmt.Errorf("cannot decode response: %w", err)}
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUserAgent(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    string
+	}{
+		{name: "with version", version: "1.0.80", want: "claude-code/1.0.80"},
+		{name: "without version", version: "", want: "claude-code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UserAgent(tt.version); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFetchUsageSendsClaudeCodeHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != UsagePath {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("unexpected Authorization header %q", got)
+		}
+		if got := r.Header.Get("anthropic-beta"); got != OAuthBeta {
+			t.Errorf("unexpected anthropic-beta header %q", got)
+		}
+		if got := r.Header.Get("User-Agent"); got != "claude-code/1.0.80" {
+			t.Errorf("unexpected User-Agent %q", got)
+		}
+		w.Write([]byte(`{"five_hour":{"utilization":12,"resets_at":"2025-01-01T00:00:00Z"},"seven_day":{"utilization":34}}`))
+	}))
+	defer server.Close()
+
+	client := New(Options{BaseURL: server.URL, UserAgent: UserAgent("1.0.80")})
+
+	usage, err := client.FetchUsage(context.Background(), "token")
+	if err != nil {
+		t.Fatalf("expected usage: %v", err)
+	}
+	if usage.FiveHour.Utilization != 12 || usage.SevenDay.Utilization != 34 {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+}
+
+func TestFetchUsageClassifiesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   ErrorKind
+	}{
+		{name: "unauthorized", status: http.StatusUnauthorized, want: KindAuth},
+		{name: "forbidden", status: http.StatusForbidden, want: KindAuth},
+		{name: "rate limited", status: http.StatusTooManyRequests, want: KindRateLimited},
+		{name: "server error", status: http.StatusBadGateway, want: KindServer},
+		{name: "not found", status: http.StatusNotFound, want: KindUnexpected},
+		{name: "malformed body", status: http.StatusOK, body: "<html>", want: KindMalformed},
+		{name: "error object", status: http.StatusOK, body: `{"error":{"message":"nope"}}`, want: KindAPI},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := New(Options{BaseURL: server.URL}).FetchUsage(context.Background(), "token")
+			if got := KindOf(err); got != tt.want {
+				t.Fatalf("expected %q, got %q (%v)", tt.want, got, err)
+			}
+		})
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestFetchUsageClassifiesTransportErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	offlineURL := server.URL
+	server.Close()
+
+	_, err := New(Options{BaseURL: offlineURL}).FetchUsage(context.Background(), "token")
+	if got := KindOf(err); got != KindOffline {
+		t.Fatalf("expected offline for refused connection, got %q (%v)", got, err)
+	}
+
+	slow := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+	_, err = New(Options{Transport: slow, Timeout: 10 * time.Millisecond}).FetchUsage(context.Background(), "token")
+	if got := KindOf(err); got != KindTimeout {
+		t.Fatalf("expected timeout, got %q (%v)", got, err)
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		w.Write([]byte(`{"access_token":"new","refresh_token":"rotated","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	token, err := New(Options{}).RefreshToken(context.Background(), server.URL, "client", "old")
+	if err != nil {
+		t.Fatalf("expected token: %v", err)
+	}
+	if token.AccessToken != "new" || token.RefreshToken != "rotated" || token.ExpiresIn != 3600 {
+		t.Fatalf("unexpected token response %+v", token)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: 0},
+		{value: "120", want: 2 * time.Minute},
+		{value: "-5", want: 0},
+		{value: "Wed, 01 Jan 2025 12:05:00 GMT", want: 5 * time.Minute},
+		{value: "Wed, 01 Jan 2025 11:00:00 GMT", want: 0},
+		{value: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Fatalf("parseRetryAfter(%q): expected %v, got %v", tt.value, tt.want, got)
+		}
+	}
+}
+
+func TestFetchUsageReportsRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "90")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := New(Options{BaseURL: server.URL}).FetchUsage(context.Background(), "token")
+	if got := RetryAfterOf(err); got != 90*time.Second {
+		t.Fatalf("expected 90s Retry-After, got %v", got)
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorKind classifies why a request failed
+type ErrorKind string
+
+// Error kinds returned by the client
+const (
+	KindAuth        ErrorKind = "auth"         // 401/403: token expired, revoked or missing scope
+	KindRateLimited ErrorKind = "rate-limited" // 429
+	KindServer      ErrorKind = "server"       // 5xx
+	KindOffline     ErrorKind = "offline"      // DNS failure, connection refused, no route
+	KindTimeout     ErrorKind = "timeout"      // request exceeded its deadline
+	KindMalformed   ErrorKind = "malformed"    // response body could not be decoded
+	KindAPI         ErrorKind = "api"          // 200 response carrying an error object
+	KindUnexpected  ErrorKind = "unexpected"   // any other status or failure
+)
+
+// Error is a classified API failure
+type Error struct {
+	Kind       ErrorKind
+	StatusCode int
+	// RetryAfter is the delay requested by the server's Retry-After header, if any
+	RetryAfter time.Duration
+	Message    string
+	Err        error
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.StatusCode != 0:
+		return fmt.Sprintf("%s: API returned status %d", e.Kind, e.StatusCode)
+	case e.Message != "":
+		return fmt.Sprintf("%s: %s", e.Kind, e.Message)
+	case e.Err != nil:
+		return fmt.Sprintf("%s: %v", e.Kind, e.Err)
+	default:
+		return string(e.Kind)
+	}
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf returns the classification of err, or KindUnexpected for
+// errors that did not come from this package
+func KindOf(err error) ErrorKind {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Kind
+	}
+	return KindUnexpected
+}
+
+// RetryAfterOf returns the Retry-After delay carried by err, or zero
+func RetryAfterOf(err error) time.Duration {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
+
+// statusError classifies a non-200 response
+func statusError(resp *http.Response) *Error {
+	err := &Error{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		err.Kind = KindAuth
+	case resp.StatusCode == http.StatusTooManyRequests:
+		err.Kind = KindRateLimited
+	case resp.StatusCode >= 500:
+		err.Kind = KindServer
+	default:
+		err.Kind = KindUnexpected
+	}
+	return err
+}
+
+// transportError classifies a failure to get any response at all
+func transportError(err error) *Error {
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), os.IsTimeout(err),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return &Error{Kind: KindTimeout, Err: err}
+	case errors.As(err, &dnsErr), errors.As(err, &opErr):
+		return &Error{Kind: KindOffline, Err: err}
+	default:
+		return &Error{Kind: KindUnexpected, Err: err}
+	}
+}
+
+// parseRetryAfter reads a Retry-After value given either as delay seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+
+	return 0
+}
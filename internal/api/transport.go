@@ -0,0 +1,78 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"ccstatus/internal/config"
+)
+
+// Environment overrides for the network settings in ccstatus.json
+const (
+	BaseURLEnv = "CCSTATUS_API_BASE_URL"
+	ProxyEnv   = "CCSTATUS_PROXY"
+	CAFileEnv  = "CCSTATUS_CA_FILE"
+)
+
+// BaseURL returns the API origin, preferring CCSTATUS_API_BASE_URL over the config file
+func BaseURL(cfg *config.CCStatusConfig) string {
+	base := settingWithEnv(BaseURLEnv, cfg.APIBaseURL)
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	return strings.TrimRight(base, "/")
+}
+
+// NewTransport returns a transport with the configured proxy and CA bundle.
+// Without a proxy setting the standard HTTPS_PROXY/NO_PROXY variables are honored.
+func NewTransport(cfg *config.CCStatusConfig) (http.RoundTripper, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if proxy := settingWithEnv(ProxyEnv, cfg.ProxyURL); proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %q: %w", proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if caFile := settingWithEnv(CAFileEnv, cfg.CAFile); caFile != "" {
+		pool, err := loadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return transport, nil
+}
+
+// loadCertPool adds the PEM certificates in path to the system roots
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read CA file: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", path)
+	}
+	return pool, nil
+}
+
+// settingWithEnv returns the environment variable if set, otherwise the config value
+func settingWithEnv(env, value string) string {
+	if fromEnv := strings.TrimSpace(os.Getenv(env)); fromEnv != "" {
+		return fromEnv
+	}
+	return value
+}
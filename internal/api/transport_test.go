@@ -0,0 +1,111 @@
+package api
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ccstatus/internal/config"
+)
+
+func TestBaseURLPrefersEnvOverConfig(t *testing.T) {
+	cfg := config.DefaultCCStatusConfig()
+	t.Setenv(BaseURLEnv, "")
+
+	if got := BaseURL(cfg); got != DefaultBaseURL {
+		t.Fatalf("expected default base URL, got %q", got)
+	}
+
+	cfg.APIBaseURL = "http://localhost:9000/"
+	if got := BaseURL(cfg); got != "http://localhost:9000" {
+		t.Fatalf("expected configured base URL, got %q", got)
+	}
+
+	t.Setenv(BaseURLEnv, "http://mock.test")
+	if got := BaseURL(cfg); got != "http://mock.test" {
+		t.Fatalf("expected env base URL, got %q", got)
+	}
+}
+
+func TestNewTransportUsesConfiguredProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+
+	t.Setenv(ProxyEnv, "")
+	cfg := config.DefaultCCStatusConfig()
+	cfg.ProxyURL = proxy.URL
+
+	transport, err := NewTransport(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: transport, Timeout: time.Second}
+	resp, err := client.Get("http://api.example.test/api/oauth/usage")
+	if err != nil {
+		t.Fatalf("expected request through proxy: %v", err)
+	}
+	resp.Body.Close()
+
+	if proxied != "http://api.example.test/api/oauth/usage" {
+		t.Fatalf("expected proxy to receive the request, got %q", proxied)
+	}
+}
+
+func TestNewTransportTrustsCustomCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(block), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(CAFileEnv, "")
+	t.Setenv(ProxyEnv, "")
+	cfg := config.DefaultCCStatusConfig()
+
+	transport, err := NewTransport(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: transport, Timeout: time.Second}
+	if _, err := client.Get(server.URL); err == nil {
+		t.Fatal("expected untrusted certificate to be rejected")
+	}
+
+	cfg.CAFile = caFile
+	transport, err = NewTransport(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = &http.Client{Transport: transport, Timeout: time.Second}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected custom CA to be trusted: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestNewTransportRejectsInvalidCAFile(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(CAFileEnv, caFile)
+	if _, err := NewTransport(config.DefaultCCStatusConfig()); err == nil {
+		t.Fatal("expected error for CA file without certificates")
+	}
+}
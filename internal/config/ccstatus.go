@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 const (
@@ -19,6 +21,70 @@ type CCStatusConfig struct {
 	ShowWeeklyUsage  bool `json:"show_weekly_usage"`
 	ShowResetTimes   bool `json:"show_reset_times"`
 	ShowGitBranch    bool `json:"show_git_branch"`
+	// ShowDataAge adds how long ago stale values were fetched (e.g. "· 12m ago")
+	ShowDataAge bool `json:"show_data_age"`
+	// MaxStale is how old cached values may be, as a Go duration, before they
+	// are replaced by placeholders
+	MaxStale string `json:"max_stale,omitempty"`
+	// PaceMode colors usage by how it compares with the elapsed share of its
+	// window (ahead, on or behind pace) instead of by absolute percentage
+	PaceMode bool `json:"pace_mode"`
+	// ShowSparkline adds the recent session utilization as a sparkline
+	ShowSparkline bool `json:"show_sparkline"`
+	// SparklineLength is how many snapshots the sparkline shows; 0 means 8
+	SparklineLength int `json:"sparkline_length,omitempty"`
+	// SparklineASCII draws the sparkline with ASCII characters for terminals
+	// without block glyphs
+	SparklineASCII bool `json:"sparkline_ascii"`
+	// ShowSessionDelta adds how much usage the current Claude Code session
+	// has consumed (e.g. "+14% this session")
+	ShowSessionDelta bool `json:"show_session_delta"`
+	// ShowDailyBudget adds how much weekly usage can be spent per remaining
+	// working day (e.g. "≈9%/day left")
+	ShowDailyBudget bool `json:"show_daily_budget"`
+	// NonWorkingDays are weekday names (e.g. "saturday", "sun") left out
+	// of the daily budget
+	NonWorkingDays []string `json:"non_working_days,omitempty"`
+	// ShowProjection adds when the session limit will be hit at the recent
+	// burn rate (e.g. "→ 100% at 4:10pm"), computed from the usage history
+	ShowProjection bool `json:"show_projection"`
+	// ProjectionLookback is how much recent history the burn rate is
+	// computed from, as a Go duration; empty means 30m
+	ProjectionLookback string `json:"projection_lookback,omitempty"`
+
+	// BackgroundRefresh renders from cache immediately and refreshes expired
+	// usage in a detached process instead of blocking on the network
+	BackgroundRefresh bool `json:"background_refresh"`
+	// CacheTTL is how long fetched usage is reused, as a Go duration (e.g. "5m")
+	CacheTTL string `json:"cache_ttl,omitempty"`
+	// AdaptiveTTL shortens the cache TTL as utilization rises or a reset
+	// approaches, and lengthens it while usage is idle
+	AdaptiveTTL bool `json:"adaptive_ttl"`
+
+	// HistoryRetentionDays is how long fetched usage is kept in the history
+	// file; 0 means the default of 90 days
+	HistoryRetentionDays int `json:"history_retention_days,omitempty"`
+	// HistoryMaxRecords caps the number of history records; 0 means 50000
+	HistoryMaxRecords int `json:"history_max_records,omitempty"`
+
+	// CredentialProviders sets the order in which OAuth credential sources
+	// are tried. Empty means the built-in default order.
+	CredentialProviders []string `json:"credential_providers,omitempty"`
+	// CredentialCommand is a shell command that prints the OAuth token or
+	// credentials blob, used by the "command" provider
+	CredentialCommand string `json:"credential_command,omitempty"`
+
+	// TokenEndpoint overrides the OAuth endpoint used to refresh expired tokens
+	TokenEndpoint string `json:"token_endpoint,omitempty"`
+	// OAuthClientID overrides the OAuth client ID sent when refreshing tokens
+	OAuthClientID string `json:"oauth_client_id,omitempty"`
+
+	// APIBaseURL overrides the Anthropic API origin (e.g. a local mock server)
+	APIBaseURL string `json:"api_base_url,omitempty"`
+	// ProxyURL routes API requests through an explicit HTTP(S) proxy
+	ProxyURL string `json:"proxy_url,omitempty"`
+	// CAFile is a PEM bundle of extra trusted certificates for a private CA
+	CAFile string `json:"ca_file,omitempty"`
 }
 
 // DefaultCCStatusConfig returns the default configuration
@@ -31,13 +97,48 @@ func DefaultCCStatusConfig() *CCStatusConfig {
 	}
 }
 
+// ParseDuration parses a duration setting such as "5m" or "1h30m",
+// returning fallback when value is empty, invalid or not positive
+func ParseDuration(value string, fallback time.Duration) time.Duration {
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+// ParseWeekdays parses weekday names, full or abbreviated to three letters
+// and in any case. Names that are not weekdays are returned in invalid.
+func ParseWeekdays(names []string) (days map[time.Weekday]bool, invalid []string) {
+	days = make(map[time.Weekday]bool)
+	for _, name := range names {
+		key := strings.ToLower(strings.TrimSpace(name))
+		found := false
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			full := strings.ToLower(d.String())
+			if key == full || key == full[:3] {
+				days[d] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			invalid = append(invalid, name)
+		}
+	}
+	return days, invalid
+}
+
 // GetCCStatusConfigPath returns the path to ~/.claude/ccstatus.json
 func GetCCStatusConfigPath() (string, error) {
-	home, err := os.UserHomeDir()
+	dir, err := GetConfigDir()
 	if err != nil {
-		return "", fmt.Errorf("cannot determine home directory: %w", err)
+		return "", err
 	}
-	return filepath.Join(home, ConfigDir, CCStatusConfigFile), nil
+	return filepath.Join(dir, CCStatusConfigFile), nil
 }
 
 // LoadCCStatusConfig loads the ccstatus configuration from disk
@@ -56,12 +157,13 @@ func LoadCCStatusConfig() (*CCStatusConfig, error) {
 		return DefaultCCStatusConfig(), fmt.Errorf("cannot read config file: %w", err)
 	}
 
-	var cfg CCStatusConfig
-	if err := json.Unmarshal(data, &cfg); err != nil {
+	// Start from defaults so keys missing from older config files keep their default values
+	cfg := DefaultCCStatusConfig()
+	if err := json.Unmarshal(data, cfg); err != nil {
 		return DefaultCCStatusConfig(), fmt.Errorf("cannot parse config file: %w", err)
 	}
 
-	return &cfg, nil
+	return cfg, nil
 }
 
 // SaveCCStatusConfig saves the ccstatus configuration to disk
@@ -16,8 +16,15 @@ const (
 	SettingsFile = "settings.json"
 	// BackupPrefix is the prefix for backup files
 	BackupPrefix = "settings.backup"
+	// ConfigDirEnv is the environment variable Claude Code reads its config directory from
+	ConfigDirEnv = "CLAUDE_CONFIG_DIR"
+	// GlobalConfigFile is Claude Code's state file, which records the signed-in account
+	GlobalConfigFile = ".claude.json"
 )
 
+// configDirOverride is set by the --config-dir flag and takes precedence over ConfigDirEnv
+var configDirOverride string
+
 // Settings represents the Claude Code settings.json structure.
 // We use map[string]any to preserve unknown fields.
 type Settings map[string]any
@@ -27,17 +34,59 @@ type StatuslineConfig struct {
 	Command string `json:"command"`
 }
 
+// SetConfigDir overrides the Claude config directory for the rest of the process
+func SetConfigDir(dir string) {
+	configDirOverride = dir
+}
+
+// CustomConfigDir returns the config directory selected through --config-dir or
+// CLAUDE_CONFIG_DIR, or an empty string when the default ~/.claude is in use
+func CustomConfigDir() string {
+	if configDirOverride != "" {
+		return configDirOverride
+	}
+	return os.Getenv(ConfigDirEnv)
+}
+
 // GetConfigPath returns the path to ~/.claude/settings.json
 func GetConfigPath() (string, error) {
+	dir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, SettingsFile), nil
+}
+
+// GetGlobalConfigPath returns the path to Claude Code's .claude.json. It lives
+// inside a custom config directory, and next to ~/.claude otherwise.
+func GetGlobalConfigPath() (string, error) {
+	if CustomConfigDir() != "" {
+		dir, err := GetConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, GlobalConfigFile), nil
+	}
+
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("cannot determine home directory: %w", err)
 	}
-	return filepath.Join(home, ConfigDir, SettingsFile), nil
+	return filepath.Join(home, GlobalConfigFile), nil
 }
 
-// GetConfigDir returns the path to ~/.claude/
+// GetConfigDir returns the Claude config directory. It honors --config-dir and
+// CLAUDE_CONFIG_DIR so each Claude Code profile gets its own files, and falls
+// back to ~/.claude/
 func GetConfigDir() (string, error) {
+	if custom := CustomConfigDir(); custom != "" {
+		dir, err := filepath.Abs(custom)
+		if err != nil {
+			return "", fmt.Errorf("cannot resolve config directory: %w", err)
+		}
+		return dir, nil
+	}
+
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("cannot determine home directory: %w", err)
@@ -307,3 +356,26 @@ func GetStatuslineObject(settings Settings) map[string]any {
 
 	return statuslineMap
 }
+
+// WriteFileAtomic writes data to a temp file in the same directory and renames
+// it over path, so concurrent readers see either the old or the new contents
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
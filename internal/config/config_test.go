@@ -1,6 +1,11 @@
 package config
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
 
 func TestSetStatuslineCommandCreatesCommandType(t *testing.T) {
 	settings := Settings{}
@@ -81,3 +86,79 @@ func TestRemoveStatuslinePreservesOtherFields(t *testing.T) {
 		t.Fatalf("expected padding to be preserved, got %v", got)
 	}
 }
+
+func TestLoadCCStatusConfigKeepsDefaultsForMissingKeys(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, ConfigDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(`{"show_git_branch": true, "credential_providers": ["env", "file"]}`)
+	if err := os.WriteFile(filepath.Join(dir, CCStatusConfigFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadCCStatusConfig()
+	if err != nil {
+		t.Fatalf("expected config to load: %v", err)
+	}
+	if !cfg.ShowSessionUsage || !cfg.ShowWeeklyUsage || !cfg.ShowResetTimes {
+		t.Fatal("expected missing keys to keep their defaults")
+	}
+	if !cfg.ShowGitBranch {
+		t.Fatal("expected show_git_branch to be read from file")
+	}
+	if len(cfg.CredentialProviders) != 2 || cfg.CredentialProviders[0] != "env" {
+		t.Fatalf("expected credential providers to be read, got %v", cfg.CredentialProviders)
+	}
+}
+
+func TestGetConfigDirHonorsEnvAndOverride(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(ConfigDirEnv, "")
+	t.Cleanup(func() { SetConfigDir("") })
+
+	dir, err := GetConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, ConfigDir); dir != want {
+		t.Fatalf("expected default %q, got %q", want, dir)
+	}
+
+	work := filepath.Join(home, "work-profile")
+	t.Setenv(ConfigDirEnv, work)
+
+	path, err := GetCCStatusConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(work, CCStatusConfigFile); path != want {
+		t.Fatalf("expected %q from %s, got %q", want, ConfigDirEnv, path)
+	}
+
+	personal := filepath.Join(home, "personal-profile")
+	SetConfigDir(personal)
+
+	path, err = GetConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(personal, SettingsFile); path != want {
+		t.Fatalf("expected override %q to win, got %q", want, path)
+	}
+}
+
+func TestParseWeekdays(t *testing.T) {
+	days, invalid := ParseWeekdays([]string{"Saturday", "sun", " FRI ", "someday"})
+
+	if len(days) != 3 || !days[time.Saturday] || !days[time.Sunday] || !days[time.Friday] {
+		t.Fatalf("expected Friday, Saturday and Sunday, got %v", days)
+	}
+	if len(invalid) != 1 || invalid[0] != "someday" {
+		t.Fatalf("expected someday to be invalid, got %v", invalid)
+	}
+}
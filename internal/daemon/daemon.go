@@ -0,0 +1,206 @@
+// Package daemon serves the latest usage snapshot over a Unix domain socket.
+// A long-lived `ccstatus daemon` process owns the token and the polling; each
+// statusline invocation only asks the socket for the current snapshot.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"ccstatus/internal/api"
+	"ccstatus/internal/config"
+)
+
+const (
+	// SocketFile is the daemon socket inside the Claude config directory
+	SocketFile = "ccstatus.sock"
+	// SnapshotPath is the HTTP path serving the current Snapshot
+	SnapshotPath = "/v1/usage"
+
+	// listenFDsStart is the first file descriptor passed by systemd socket activation
+	listenFDsStart = 3
+
+	// queryTimeout bounds a statusline query, so a wedged daemon costs
+	// at most this long before the direct path takes over
+	queryTimeout = 250 * time.Millisecond
+)
+
+// ErrRunning is returned by Listen when another daemon already serves the socket
+var ErrRunning = errors.New("daemon is already running")
+
+// ErrNoSnapshot is returned by Query while the daemon has not polled yet
+var ErrNoSnapshot = errors.New("daemon has no usage yet")
+
+// Snapshot is the daemon's view of the current account's usage
+type Snapshot struct {
+	// Account is the cache key of the account the usage belongs to
+	Account string             `json:"account"`
+	Usage   *api.UsageResponse `json:"usage,omitempty"`
+	// FetchedAt is when Usage was fetched from the API
+	FetchedAt time.Time `json:"fetched_at,omitempty"`
+	// TTL is how long Usage is considered fresh after FetchedAt
+	TTL time.Duration `json:"ttl"`
+	// ErrorKind and ErrorMessage describe the last failed poll, if any
+	ErrorKind    api.ErrorKind `json:"error_kind,omitempty"`
+	ErrorMessage string        `json:"error_message,omitempty"`
+}
+
+// Fresh reports whether the snapshot's usage is within its TTL at now
+func (s *Snapshot) Fresh(now time.Time) bool {
+	return s.Usage != nil && now.Sub(s.FetchedAt) <= s.TTL
+}
+
+// GetSocketPath returns the daemon socket of the active Claude profile
+func GetSocketPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, SocketFile), nil
+}
+
+// Server serves the most recent Snapshot
+type Server struct {
+	mu       sync.RWMutex
+	snapshot *Snapshot
+}
+
+// Update replaces the snapshot served to clients
+func (s *Server) Update(snapshot *Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshot = snapshot
+}
+
+func (s *Server) current() *Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.snapshot
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != SnapshotPath || r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+
+	snapshot := s.current()
+	if snapshot == nil {
+		http.Error(w, ErrNoSnapshot.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(snapshot)
+}
+
+// Serve answers queries on listener until ctx is cancelled
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	srv := &http.Server{Handler: s, ReadHeaderTimeout: time.Second}
+
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Listen creates the socket at path, readable only by the current user.
+// A socket left behind by a crashed daemon is replaced; a live one yields ErrRunning.
+func Listen(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("cannot create socket directory: %w", err)
+	}
+
+	if conn, err := net.DialTimeout("unix", path, queryTimeout); err == nil {
+		conn.Close()
+		return nil, ErrRunning
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("cannot remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("cannot restrict socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// Activated returns the socket passed by systemd socket activation, or nil
+// when the process was not socket-activated
+func Activated() (net.Listener, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, nil
+	}
+
+	// Keep the variables from leaking into child processes
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	file := os.NewFile(listenFDsStart, "systemd-socket")
+	defer file.Close()
+
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot use activated socket: %w", err)
+	}
+	return listener, nil
+}
+
+// Query asks the daemon listening on path for its snapshot. It fails fast
+// when no daemon is running.
+func Query(path string) (*Snapshot, error) {
+	client := &http.Client{
+		Timeout: queryTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Get("http://ccstatus" + SnapshotPath)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		return nil, ErrNoSnapshot
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("daemon returned HTTP %d", resp.StatusCode)
+	}
+
+	var snapshot Snapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("cannot decode daemon response: %w", err)
+	}
+	return &snapshot, nil
+}
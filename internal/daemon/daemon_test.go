@@ -0,0 +1,113 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ccstatus/internal/api"
+)
+
+// socketPath returns a socket path short enough for the sun_path limit
+func socketPath(t *testing.T) string {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "ccs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, SocketFile)
+}
+
+func serve(t *testing.T, path string, server *Server) {
+	t.Helper()
+
+	listener, err := Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = server.Serve(ctx, listener)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestQueryReturnsLatestSnapshot(t *testing.T) {
+	path := socketPath(t)
+	server := &Server{}
+	serve(t, path, server)
+
+	if _, err := Query(path); !errors.Is(err, ErrNoSnapshot) {
+		t.Fatalf("expected ErrNoSnapshot before the first poll, got %v", err)
+	}
+
+	usage := &api.UsageResponse{}
+	usage.FiveHour.Utilization = 37
+	fetchedAt := time.Now().Truncate(time.Second)
+	server.Update(&Snapshot{Account: "abc", Usage: usage, FetchedAt: fetchedAt, TTL: time.Minute})
+
+	snapshot, err := Query(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Account != "abc" || snapshot.Usage.FiveHour.Utilization != 37 || !snapshot.FetchedAt.Equal(fetchedAt) {
+		t.Fatalf("unexpected snapshot %+v", snapshot)
+	}
+	if !snapshot.Fresh(fetchedAt.Add(30*time.Second)) || snapshot.Fresh(fetchedAt.Add(2*time.Minute)) {
+		t.Fatal("expected freshness to follow the TTL")
+	}
+}
+
+func TestListenRefusesLiveSocketAndReplacesStaleOne(t *testing.T) {
+	path := socketPath(t)
+
+	// A socket file without a listener is what a crashed daemon leaves behind
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	serve(t, path, &Server{})
+
+	if _, err := Listen(path); !errors.Is(err, ErrRunning) {
+		t.Fatalf("expected ErrRunning for a live daemon, got %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Fatalf("expected socket mode 0600, got %o", perm)
+	}
+}
+
+func TestQueryFailsWithoutDaemon(t *testing.T) {
+	if _, err := Query(socketPath(t)); err == nil {
+		t.Fatal("expected an error when no daemon is listening")
+	}
+}
+
+func TestActivatedIgnoresForeignListenPID(t *testing.T) {
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+
+	listener, err := Activated()
+	if err != nil || listener != nil {
+		t.Fatalf("expected no activated socket for another process, got %v, %v", listener, err)
+	}
+}
@@ -0,0 +1,70 @@
+// Package filelock provides advisory, cross-process file locks built on flock(2).
+// Locks are released automatically when the holding process exits, so a
+// crashed holder never leaves a stale lock behind.
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// pollInterval is how often Acquire retries while waiting
+const pollInterval = 10 * time.Millisecond
+
+// ErrLocked is returned by TryLock when another holder owns the lock
+var ErrLocked = errors.New("lock is held by another process")
+
+// Lock is a held advisory lock
+type Lock struct {
+	file *os.File
+}
+
+// TryLock acquires an exclusive lock on path without waiting.
+// It returns ErrLocked if the lock is already held.
+func TryLock(path string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("cannot create lock directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open lock file: %w", err)
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrLocked
+		}
+		return nil, fmt.Errorf("cannot lock %s: %w", path, err)
+	}
+
+	return &Lock{file: file}, nil
+}
+
+// Acquire takes an exclusive lock on path, waiting up to timeout for the
+// current holder to release it. It returns ErrLocked if the wait times out.
+func Acquire(path string, timeout time.Duration) (*Lock, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		lock, err := TryLock(path)
+		if !errors.Is(err, ErrLocked) || !time.Now().Before(deadline) {
+			return lock, err
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// Unlock releases the lock
+func (l *Lock) Unlock() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
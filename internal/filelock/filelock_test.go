@@ -0,0 +1,91 @@
+package filelock
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTryLockIsExclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "test.lock")
+
+	first, err := TryLock(path)
+	if err != nil {
+		t.Fatalf("expected first lock to succeed: %v", err)
+	}
+
+	if _, err := TryLock(path); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked while held, got %v", err)
+	}
+
+	if err := first.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := TryLock(path)
+	if err != nil {
+		t.Fatalf("expected lock to be free after unlock: %v", err)
+	}
+	second.Unlock()
+}
+
+func TestLockWaitsForHolder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.lock")
+
+	held, err := TryLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Acquire(path, 30*time.Millisecond); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected wait to time out with ErrLocked, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		held.Unlock()
+	}()
+
+	lock, err := Acquire(path, 2*time.Second)
+	if err != nil {
+		t.Fatalf("expected lock after holder released it: %v", err)
+	}
+	lock.Unlock()
+}
+
+func TestLockAllowsOneHolderAcrossGoroutines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.lock")
+
+	var active, maxActive int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock, err := Acquire(path, 5*time.Second)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer lock.Unlock()
+
+			n := atomic.AddInt32(&active, 1)
+			for {
+				seen := atomic.LoadInt32(&maxActive)
+				if n <= seen || atomic.CompareAndSwapInt32(&maxActive, seen, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&active, -1)
+		}()
+	}
+	wg.Wait()
+
+	if maxActive != 1 {
+		t.Fatalf("expected at most one holder at a time, saw %d", maxActive)
+	}
+}
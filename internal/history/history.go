@@ -0,0 +1,319 @@
+// Package history keeps every successfully fetched usage snapshot in an
+// append-only JSON Lines file under the Claude config directory. Trends,
+// burn rate and reports are computed from it.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ccstatus/internal/api"
+	"ccstatus/internal/config"
+	"ccstatus/internal/filelock"
+)
+
+const (
+	// File is the history file inside the Claude config directory
+	File = "ccstatus-history.jsonl"
+	// lockFile serializes appends with pruning
+	lockFile = "ccstatus-history.lock"
+
+	// DefaultRetentionDays is how long records are kept when not configured
+	DefaultRetentionDays = 90
+	// DefaultMaxRecords caps the file when not configured
+	DefaultMaxRecords = 50000
+
+	// pruneInterval is how often Append rewrites the file to drop old records
+	pruneInterval = 24 * time.Hour
+	// lockWait bounds how long a writer waits for another writer
+	lockWait = 2 * time.Second
+	// tailChunk is the first read size of RecordsSince; it doubles until the
+	// requested period is covered
+	tailChunk = 16 * 1024
+)
+
+// Record is one fetched usage snapshot
+type Record struct {
+	Time time.Time `json:"time"`
+	// Account is the cache key of the account the usage belongs to
+	Account  string          `json:"account"`
+	FiveHour api.UsageWindow `json:"five_hour"`
+	SevenDay api.UsageWindow `json:"seven_day"`
+}
+
+// NewRecord builds the record for usage fetched at t
+func NewRecord(t time.Time, account string, usage *api.UsageResponse) Record {
+	return Record{
+		Time:     t.UTC(),
+		Account:  account,
+		FiveHour: usage.FiveHour,
+		SevenDay: usage.SevenDay,
+	}
+}
+
+// Store is the history file of one Claude profile
+type Store struct {
+	path       string
+	retention  time.Duration
+	maxRecords int
+}
+
+// Open returns the history store of the active profile, using the retention
+// settings from cfg
+func Open(cfg *config.CCStatusConfig) (*Store, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	retentionDays := cfg.HistoryRetentionDays
+	if retentionDays <= 0 {
+		retentionDays = DefaultRetentionDays
+	}
+	maxRecords := cfg.HistoryMaxRecords
+	if maxRecords <= 0 {
+		maxRecords = DefaultMaxRecords
+	}
+
+	return &Store{
+		path:       filepath.Join(dir, File),
+		retention:  time.Duration(retentionDays) * 24 * time.Hour,
+		maxRecords: maxRecords,
+	}, nil
+}
+
+// Path returns the history file
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Append adds rec to the end of the file and prunes old records at most
+// once per pruneInterval
+func (s *Store) Append(rec Record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	lock, err := filelock.Acquire(s.lockPath(), lockWait)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("cannot open history file: %w", err)
+	}
+	defer file.Close()
+
+	// A crash mid-write leaves a partial last line; start a new line after it
+	// so the damage stays confined to that one record
+	if needsNewline(file) {
+		line = append([]byte("\n"), line...)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("cannot append to history file: %w", err)
+	}
+
+	if s.pruneDue() {
+		return s.prune(time.Now())
+	}
+	return nil
+}
+
+// Records returns every readable record in file order. Corrupted lines are
+// skipped and counted rather than failing the whole read.
+func (s *Store) Records() ([]Record, int, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("cannot open history file: %w", err)
+	}
+	defer file.Close()
+
+	return readRecords(file)
+}
+
+// RecordsSince returns the readable records at or after since. Only the end
+// of the file is read, so the cost follows the period asked for rather than
+// the size of the history.
+func (s *Store) RecordsSince(since time.Time) ([]Record, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot open history file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read history file: %w", err)
+	}
+	size := info.Size()
+
+	for chunk := int64(tailChunk); ; chunk *= 2 {
+		offset := max(size-chunk, 0)
+		buf := make([]byte, size-offset)
+		if _, err := file.ReadAt(buf, offset); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("cannot read history file: %w", err)
+		}
+
+		// The chunk usually starts mid-line; drop the partial record
+		if offset > 0 {
+			newline := bytes.IndexByte(buf, '\n')
+			if newline < 0 {
+				continue
+			}
+			buf = buf[newline+1:]
+		}
+
+		records, _, err := readRecords(bytes.NewReader(buf))
+		if err != nil {
+			return nil, err
+		}
+		if offset == 0 || (len(records) > 0 && records[0].Time.Before(since)) {
+			return Select(records, Filter{Since: since}), nil
+		}
+	}
+}
+
+// Prune drops records older than the retention period and keeps at most
+// the newest maxRecords
+func (s *Store) Prune() error {
+	lock, err := filelock.Acquire(s.lockPath(), lockWait)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	return s.prune(time.Now())
+}
+
+// prune rewrites the file; the caller holds the lock
+func (s *Store) prune(now time.Time) error {
+	records, _, err := s.Records()
+	if err != nil {
+		return err
+	}
+
+	cutoff := now.Add(-s.retention)
+	kept := records[:0]
+	for _, rec := range records {
+		if rec.Time.After(cutoff) {
+			kept = append(kept, rec)
+		}
+	}
+	if len(kept) > s.maxRecords {
+		kept = kept[len(kept)-s.maxRecords:]
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, rec := range kept {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+
+	if err := config.WriteFileAtomic(s.path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("cannot rewrite history file: %w", err)
+	}
+
+	// The lock file's modification time records the last prune
+	return os.Chtimes(s.lockPath(), now, now)
+}
+
+// pruneDue reports whether the last prune is older than pruneInterval
+func (s *Store) pruneDue() bool {
+	info, err := os.Stat(s.lockPath())
+	if err != nil {
+		return true
+	}
+	return time.Since(info.ModTime()) > pruneInterval
+}
+
+func (s *Store) lockPath() string {
+	return filepath.Join(filepath.Dir(s.path), lockFile)
+}
+
+// readRecords decodes one record per line, skipping lines that do not parse
+func readRecords(r io.Reader) ([]Record, int, error) {
+	var records []Record
+	skipped := 0
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var rec Record
+			if jsonErr := json.Unmarshal(line, &rec); jsonErr != nil || rec.Time.IsZero() {
+				skipped++
+			} else {
+				records = append(records, rec)
+			}
+		}
+
+		if err == io.EOF {
+			return records, skipped, nil
+		}
+		if err != nil {
+			return records, skipped, fmt.Errorf("cannot read history file: %w", err)
+		}
+	}
+}
+
+// needsNewline reports whether file is non-empty and does not end in a newline
+func needsNewline(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return false
+	}
+
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+		return false
+	}
+	return last[0] != '\n'
+}
+
+// Filter selects records; zero fields match everything
+type Filter struct {
+	// Since and Until bound the record time, inclusive
+	Since time.Time
+	Until time.Time
+	// Account limits records to one account key
+	Account string
+}
+
+// Match reports whether rec passes the filter
+func (f Filter) Match(rec Record) bool {
+	if !f.Since.IsZero() && rec.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && rec.Time.After(f.Until) {
+		return false
+	}
+	return f.Account == "" || rec.Account == f.Account
+}
+
+// Select returns the records that pass f, in their original order
+func Select(records []Record, f Filter) []Record {
+	var selected []Record
+	for _, rec := range records {
+		if f.Match(rec) {
+			selected = append(selected, rec)
+		}
+	}
+	return selected
+}
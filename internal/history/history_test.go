@@ -0,0 +1,207 @@
+package history
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"ccstatus/internal/api"
+	"ccstatus/internal/config"
+)
+
+func openStore(t *testing.T, cfg *config.CCStatusConfig) *Store {
+	t.Helper()
+
+	t.Setenv("HOME", t.TempDir())
+	store, err := Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func record(t time.Time, fiveHour float64) Record {
+	usage := &api.UsageResponse{}
+	usage.FiveHour.Utilization = fiveHour
+	usage.FiveHour.ResetsAt = t.Add(time.Hour).Format(time.RFC3339)
+	usage.SevenDay.Utilization = fiveHour / 2
+	return NewRecord(t, "account", usage)
+}
+
+func TestAppendAndReadRecords(t *testing.T) {
+	store := openStore(t, config.DefaultCCStatusConfig())
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		if err := store.Append(record(start.Add(time.Duration(i)*5*time.Minute), float64(10*i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, skipped, err := store.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || skipped != 0 {
+		t.Fatalf("expected 3 records and none skipped, got %d and %d", len(records), skipped)
+	}
+	last := records[2]
+	if !last.Time.Equal(start.Add(10*time.Minute)) || last.FiveHour.Utilization != 20 || last.SevenDay.Utilization != 10 || last.Account != "account" {
+		t.Fatalf("unexpected last record %+v", last)
+	}
+	if last.FiveHour.ResetsAt == "" {
+		t.Fatal("expected reset time to be recorded")
+	}
+}
+
+func TestRecordsSkipsCorruptedLines(t *testing.T) {
+	store := openStore(t, config.DefaultCCStatusConfig())
+	now := time.Now()
+
+	if err := store.Append(record(now, 10)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Garbage, a record without a time and a partial write from a crash
+	file, err := os.OpenFile(store.Path(), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("not json\n{\"account\":\"x\"}\n{\"time\":\"2026-")
+	file.Close()
+
+	if err := store.Append(record(now.Add(time.Minute), 20)); err != nil {
+		t.Fatal(err)
+	}
+
+	records, skipped, err := store.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || skipped != 3 {
+		t.Fatalf("expected 2 records and 3 skipped lines, got %d and %d", len(records), skipped)
+	}
+	if records[1].FiveHour.Utilization != 20 {
+		t.Fatalf("expected the record after a partial line to survive, got %+v", records[1])
+	}
+}
+
+func TestPruneAppliesRetentionAndMaxRecords(t *testing.T) {
+	cfg := config.DefaultCCStatusConfig()
+	cfg.HistoryRetentionDays = 7
+	cfg.HistoryMaxRecords = 3
+	store := openStore(t, cfg)
+	now := time.Now()
+
+	times := []time.Time{
+		now.Add(-10 * 24 * time.Hour), // past retention
+		now.Add(-4 * time.Hour),
+		now.Add(-3 * time.Hour),
+		now.Add(-2 * time.Hour),
+		now.Add(-time.Hour),
+	}
+	for i, ts := range times {
+		if err := store.Append(record(ts, float64(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := store.Prune(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, _, err := store.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected max_records to keep 3 records, got %d", len(records))
+	}
+	if records[0].FiveHour.Utilization != 2 || records[2].FiveHour.Utilization != 4 {
+		t.Fatalf("expected the newest records to be kept, got %+v", records)
+	}
+}
+
+func TestAppendPrunesOncePerInterval(t *testing.T) {
+	cfg := config.DefaultCCStatusConfig()
+	cfg.HistoryRetentionDays = 1
+	store := openStore(t, cfg)
+	now := time.Now()
+
+	if err := store.Append(record(now.Add(-48*time.Hour), 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pretend the last prune was two days ago
+	old := now.Add(-2 * pruneInterval)
+	if err := os.Chtimes(store.lockPath(), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Append(record(now, 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	records, _, err := store.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].FiveHour.Utilization != 2 {
+		t.Fatalf("expected the overdue prune to drop the old record, got %+v", records)
+	}
+	if store.pruneDue() {
+		t.Fatal("expected the prune to be recorded")
+	}
+}
+
+func TestSelectFiltersByTimeAndAccount(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Time: start, Account: "a"},
+		{Time: start.Add(time.Hour), Account: "b"},
+		{Time: start.Add(2 * time.Hour), Account: "a"},
+		{Time: start.Add(3 * time.Hour), Account: "a"},
+	}
+
+	got := Select(records, Filter{Since: start.Add(time.Hour), Until: start.Add(2 * time.Hour)})
+	if len(got) != 2 {
+		t.Fatalf("expected inclusive time bounds to select 2 records, got %d", len(got))
+	}
+
+	got = Select(records, Filter{Account: "a", Since: start.Add(time.Minute)})
+	if len(got) != 2 || got[0].Time != start.Add(2*time.Hour) {
+		t.Fatalf("expected account filter to select later records of a, got %+v", got)
+	}
+}
+
+func TestRecordsSinceReadsOnlyTheRequestedPeriod(t *testing.T) {
+	store := openStore(t, config.DefaultCCStatusConfig())
+	start := time.Now().Add(-10 * time.Hour).Truncate(time.Second)
+
+	// Enough records that the period spans several tail chunks
+	const count = 600
+	for i := 0; i < count; i++ {
+		if err := store.Append(record(start.Add(time.Duration(i)*time.Minute), float64(i%100))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	since := start.Add(time.Duration(count-250) * time.Minute)
+	records, err := store.RecordsSince(since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 250 {
+		t.Fatalf("expected 250 records, got %d", len(records))
+	}
+	if !records[0].Time.Equal(since) {
+		t.Fatalf("expected first record at %v, got %v", since, records[0].Time)
+	}
+
+	all, err := store.RecordsSince(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != count {
+		t.Fatalf("expected all %d records, got %d", count, len(all))
+	}
+}
@@ -0,0 +1,85 @@
+package history
+
+import "time"
+
+// minProjectionSpan is the shortest stretch of samples a rate is computed from;
+// shorter spans are dominated by rounding of the reported utilization
+const minProjectionSpan = 5 * time.Minute
+
+// Projection is the expected course of the session window
+type Projection struct {
+	// Rate is the utilization growth in percentage points per hour
+	Rate float64
+	// LimitAt is when utilization is expected to reach 100%; zero when the
+	// window resets first
+	LimitAt time.Time
+}
+
+// Safe reports whether the window is expected to reset before the limit
+func (p Projection) Safe() bool {
+	return p.LimitAt.IsZero()
+}
+
+// ProjectSession estimates when the session window resetting at resetsAt
+// reaches 100%, from the growth of the records within lookback before now.
+// records must belong to one account and be in time order. ok is false when
+// there are too few samples, the window is not active or the limit is
+// already reached.
+func ProjectSession(records []Record, resetsAt, now time.Time, lookback time.Duration) (Projection, bool) {
+	if resetsAt.IsZero() || !resetsAt.After(now) {
+		return Projection{}, false
+	}
+
+	// Only samples from the current window count: a drop in utilization
+	// means the window reset in between
+	var samples []Record
+	for _, rec := range Select(records, Filter{Since: now.Add(-lookback), Until: now}) {
+		if n := len(samples); n > 0 && rec.FiveHour.Utilization < samples[n-1].FiveHour.Utilization {
+			samples = samples[:0]
+		}
+		samples = append(samples, rec)
+	}
+	if len(samples) < 2 {
+		return Projection{}, false
+	}
+
+	first, last := samples[0], samples[len(samples)-1]
+	if last.Time.Sub(first.Time) < minProjectionSpan || last.FiveHour.Utilization >= 100 {
+		return Projection{}, false
+	}
+
+	rate := slopePerHour(samples)
+	if rate <= 0 {
+		return Projection{Rate: rate}, true
+	}
+
+	remaining := (100 - last.FiveHour.Utilization) / rate
+	limitAt := last.Time.Add(time.Duration(remaining * float64(time.Hour))).Round(time.Second)
+	if !limitAt.Before(resetsAt) {
+		return Projection{Rate: rate}, true
+	}
+	return Projection{Rate: rate, LimitAt: limitAt}, true
+}
+
+// slopePerHour fits a least-squares line through the session utilization
+// of samples and returns its slope in percentage points per hour
+func slopePerHour(samples []Record) float64 {
+	origin := samples[0].Time
+	n := float64(len(samples))
+
+	var sumX, sumY, sumXY, sumXX float64
+	for _, rec := range samples {
+		x := rec.Time.Sub(origin).Hours()
+		y := rec.FiveHour.Utilization
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denom
+}
@@ -0,0 +1,91 @@
+package history
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func sessionRecords(start time.Time, step time.Duration, utilization ...float64) []Record {
+	records := make([]Record, len(utilization))
+	for i, u := range utilization {
+		records[i] = record(start.Add(time.Duration(i)*step), u)
+	}
+	return records
+}
+
+func TestProjectSessionPredictsLimit(t *testing.T) {
+	now := time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC)
+	resetsAt := now.Add(3 * time.Hour)
+
+	// 10 points every 10 minutes: 60 points per hour, 40 left at now
+	records := sessionRecords(now.Add(-20*time.Minute), 10*time.Minute, 40, 50, 60)
+
+	projection, ok := ProjectSession(records, resetsAt, now, 30*time.Minute)
+	if !ok {
+		t.Fatal("expected a projection")
+	}
+	if math.Abs(projection.Rate-60) > 1e-9 {
+		t.Fatalf("expected 60 points per hour, got %v", projection.Rate)
+	}
+	if want := now.Add(40 * time.Minute); !projection.LimitAt.Equal(want) {
+		t.Fatalf("expected limit at %v, got %v", want, projection.LimitAt)
+	}
+}
+
+func TestProjectSessionSafeWhenResetComesFirst(t *testing.T) {
+	now := time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC)
+
+	records := sessionRecords(now.Add(-20*time.Minute), 10*time.Minute, 40, 41, 42)
+	projection, ok := ProjectSession(records, now.Add(time.Hour), now, 30*time.Minute)
+	if !ok || !projection.Safe() {
+		t.Fatalf("expected a safe projection, got %+v (ok=%v)", projection, ok)
+	}
+
+	flat := sessionRecords(now.Add(-20*time.Minute), 10*time.Minute, 40, 40, 40)
+	if projection, ok := ProjectSession(flat, now.Add(time.Hour), now, 30*time.Minute); !ok || !projection.Safe() {
+		t.Fatalf("expected idle usage to be safe, got %+v (ok=%v)", projection, ok)
+	}
+}
+
+func TestProjectSessionUsesOnlyTheLookbackAndCurrentWindow(t *testing.T) {
+	now := time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC)
+	resetsAt := now.Add(4 * time.Hour)
+
+	// A steep climb before the lookback and a reset at 30 must be ignored,
+	// leaving 30, 35, 40 over 20 minutes: 30 points per hour
+	records := sessionRecords(now.Add(-60*time.Minute), 10*time.Minute, 10, 50, 90, 95, 30, 35, 40)
+
+	projection, ok := ProjectSession(records, resetsAt, now, 30*time.Minute)
+	if !ok {
+		t.Fatal("expected a projection")
+	}
+	if math.Abs(projection.Rate-30) > 1e-9 {
+		t.Fatalf("expected 30 points per hour, got %v", projection.Rate)
+	}
+	if want := now.Add(2 * time.Hour); !projection.LimitAt.Equal(want) {
+		t.Fatalf("expected limit at %v, got %v", want, projection.LimitAt)
+	}
+}
+
+func TestProjectSessionNeedsEnoughHistory(t *testing.T) {
+	now := time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC)
+	resetsAt := now.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		records []Record
+		resets  time.Time
+	}{
+		{name: "single sample", records: sessionRecords(now.Add(-time.Minute), time.Minute, 40), resets: resetsAt},
+		{name: "short span", records: sessionRecords(now.Add(-2*time.Minute), time.Minute, 40, 41, 42), resets: resetsAt},
+		{name: "at limit", records: sessionRecords(now.Add(-20*time.Minute), 10*time.Minute, 90, 95, 100), resets: resetsAt},
+		{name: "window over", records: sessionRecords(now.Add(-20*time.Minute), 10*time.Minute, 40, 50, 60), resets: now.Add(-time.Minute)},
+	}
+
+	for _, tt := range tests {
+		if projection, ok := ProjectSession(tt.records, tt.resets, now, 30*time.Minute); ok {
+			t.Fatalf("%s: expected no projection, got %+v", tt.name, projection)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+// Package service generates the per-user service definitions that start the
+// ccstatus daemon on login: systemd user units with socket activation on
+// Linux and a launchd agent on macOS. Generation is pure so the files can be
+// previewed and tested; installing them is left to the caller.
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// Name is the base name of the units and the launchd label suffix
+	Name = "ccstatus"
+	// launchdLabelPrefix namespaces the launchd label
+	launchdLabelPrefix = "io.github.tharuxpert."
+)
+
+// Options describe the daemon a service definition starts
+type Options struct {
+	// GOOS selects the service manager ("linux" or "darwin")
+	GOOS string
+	// Home is the user's home directory
+	Home string
+	// ConfigHome overrides $XDG_CONFIG_HOME for systemd units
+	ConfigHome string
+	// Executable is the absolute path of the ccstatus binary
+	Executable string
+	// ConfigDir is the custom Claude config directory, or empty for ~/.claude
+	ConfigDir string
+	// SocketPath is where the daemon serves usage
+	SocketPath string
+}
+
+// File is a generated service definition
+type File struct {
+	Path    string
+	Content string
+}
+
+// InstanceName returns the unit name for the profile. A custom config
+// directory gets a suffix derived from its path so profiles do not collide.
+func (o Options) InstanceName() string {
+	if o.ConfigDir == "" {
+		return Name
+	}
+	sum := sha256.Sum256([]byte(o.ConfigDir))
+	return Name + "-" + hex.EncodeToString(sum[:])[:8]
+}
+
+// LaunchdLabel returns the launchd job label
+func (o Options) LaunchdLabel() string {
+	return launchdLabelPrefix + o.InstanceName()
+}
+
+// daemonArgs returns the command line that runs the daemon
+func (o Options) daemonArgs() []string {
+	args := []string{o.Executable, "daemon"}
+	if o.ConfigDir != "" {
+		args = append(args, "--config-dir", o.ConfigDir)
+	}
+	return args
+}
+
+// Files returns the service definitions for o.GOOS
+func Files(o Options) ([]File, error) {
+	switch o.GOOS {
+	case "linux":
+		return systemdFiles(o), nil
+	case "darwin":
+		return []File{launchdFile(o)}, nil
+	default:
+		return nil, fmt.Errorf("no service manager support for %s", o.GOOS)
+	}
+}
+
+// ActivateCommands returns the commands that load and start installed files.
+// When replacing existing files, a running systemd service is restarted so
+// it picks up the new definition; launchd needs ReplaceCommands first.
+func ActivateCommands(o Options, replacing bool) [][]string {
+	switch o.GOOS {
+	case "linux":
+		name := o.InstanceName()
+		commands := [][]string{
+			{"systemctl", "--user", "daemon-reload"},
+			{"systemctl", "--user", "enable", "--now", name + ".socket"},
+			{"systemctl", "--user", "enable", name + ".service"},
+		}
+		if replacing {
+			commands = append(commands, []string{"systemctl", "--user", "restart", name + ".service"})
+		}
+		return commands
+	case "darwin":
+		return [][]string{
+			{"launchctl", "bootstrap", launchdDomain(), launchdFile(o).Path},
+		}
+	}
+	return nil
+}
+
+// ReplaceCommands returns the commands that unload a service loaded from
+// older files before ActivateCommands loads the new ones. launchd refuses
+// to bootstrap a job that is still loaded. They may fail if it is not loaded.
+func ReplaceCommands(o Options) [][]string {
+	if o.GOOS == "darwin" {
+		return [][]string{
+			{"launchctl", "bootout", launchdDomain() + "/" + o.LaunchdLabel()},
+		}
+	}
+	return nil
+}
+
+// DeactivateCommands returns the commands that stop and unload the service.
+// They are run before the files are removed and may fail if it is not loaded.
+func DeactivateCommands(o Options) [][]string {
+	switch o.GOOS {
+	case "linux":
+		name := o.InstanceName()
+		return [][]string{
+			{"systemctl", "--user", "disable", "--now", name + ".service", name + ".socket"},
+		}
+	case "darwin":
+		return [][]string{
+			{"launchctl", "bootout", launchdDomain() + "/" + o.LaunchdLabel()},
+		}
+	}
+	return nil
+}
+
+// ReloadCommands returns the commands run after the files are removed
+func ReloadCommands(o Options) [][]string {
+	if o.GOOS == "linux" {
+		return [][]string{{"systemctl", "--user", "daemon-reload"}}
+	}
+	return nil
+}
+
+// StatusCommand returns the command that reports whether the service is loaded
+func StatusCommand(o Options) []string {
+	switch o.GOOS {
+	case "linux":
+		name := o.InstanceName()
+		return []string{"systemctl", "--user", "is-active", name + ".socket", name + ".service"}
+	case "darwin":
+		return []string{"launchctl", "print", launchdDomain() + "/" + o.LaunchdLabel()}
+	}
+	return nil
+}
+
+// Changed reports whether the file on disk differs from f. A missing file
+// counts as changed; existing reports whether there is something to back up.
+func Changed(f File) (changed, existing bool) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return true, false
+	}
+	return !bytes.Equal(data, []byte(f.Content)), true
+}
+
+// Backup copies an existing file next to itself with a timestamp suffix
+func Backup(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s for backup: %w", path, err)
+	}
+
+	backupPath := fmt.Sprintf("%s.backup.%s", path, time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+		return "", fmt.Errorf("cannot write backup file: %w", err)
+	}
+	return backupPath, nil
+}
+
+// Write creates f, including its directory
+func Write(f File) error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+		return fmt.Errorf("cannot create %s: %w", filepath.Dir(f.Path), err)
+	}
+	if err := os.WriteFile(f.Path, []byte(f.Content), 0644); err != nil {
+		return fmt.Errorf("cannot write %s: %w", f.Path, err)
+	}
+	return nil
+}
+
+// systemdFiles returns a socket unit that owns the daemon socket and a
+// service unit it activates. The service is also enabled for login so
+// polling starts before the first statusline render.
+func systemdFiles(o Options) []File {
+	dir := o.ConfigHome
+	if dir == "" {
+		dir = filepath.Join(o.Home, ".config")
+	}
+	dir = filepath.Join(dir, "systemd", "user")
+	name := o.InstanceName()
+
+	socket := fmt.Sprintf(`[Unit]
+Description=ccstatus usage daemon socket
+
+[Socket]
+ListenStream=%s
+SocketMode=0600
+RemoveOnStop=true
+
+[Install]
+WantedBy=sockets.target
+`, o.SocketPath)
+
+	service := fmt.Sprintf(`[Unit]
+Description=ccstatus usage daemon
+Requires=%[1]s.socket
+After=%[1]s.socket network-online.target
+
+[Service]
+ExecStart=%[2]s
+Restart=on-failure
+RestartSec=10
+
+[Install]
+WantedBy=default.target
+`, name, systemdCommandLine(o.daemonArgs()))
+
+	return []File{
+		{Path: filepath.Join(dir, name+".socket"), Content: socket},
+		{Path: filepath.Join(dir, name+".service"), Content: service},
+	}
+}
+
+// launchdFile returns a LaunchAgent that runs the daemon at login and
+// restarts it if it exits with an error
+func launchdFile(o Options) File {
+	var args strings.Builder
+	for _, arg := range o.daemonArgs() {
+		fmt.Fprintf(&args, "\t\t<string>%s</string>\n", xmlEscape(arg))
+	}
+
+	label := o.LaunchdLabel()
+	logPath := filepath.Join(o.Home, "Library", "Logs", o.InstanceName()+".log")
+
+	content := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+%s	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>ProcessType</key>
+	<string>Background</string>
+	<key>StandardErrorPath</key>
+	<string>%s</string>
+</dict>
+</plist>
+`, xmlEscape(label), args.String(), xmlEscape(logPath))
+
+	return File{
+		Path:    filepath.Join(o.Home, "Library", "LaunchAgents", label+".plist"),
+		Content: content,
+	}
+}
+
+// systemdCommandLine quotes args for an ExecStart= line
+func systemdCommandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg != "" && !strings.ContainsAny(arg, " \t\"'\\$%") {
+			quoted[i] = arg
+			continue
+		}
+		escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `$$`, `%`, `%%`).Replace(arg)
+		quoted[i] = `"` + escaped + `"`
+	}
+	return strings.Join(quoted, " ")
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// launchdDomain returns the GUI domain of the current user
+func launchdDomain() string {
+	return fmt.Sprintf("gui/%d", os.Getuid())
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func linuxOptions() Options {
+	return Options{
+		GOOS:       "linux",
+		Home:       "/home/dev",
+		Executable: "/usr/local/bin/ccstatus",
+		SocketPath: "/home/dev/.claude/ccstatus.sock",
+	}
+}
+
+func TestSystemdUnitsUseSocketActivation(t *testing.T) {
+	files, err := Files(linuxOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected socket and service units, got %d files", len(files))
+	}
+
+	socket, svc := files[0], files[1]
+	if socket.Path != "/home/dev/.config/systemd/user/ccstatus.socket" {
+		t.Fatalf("unexpected socket unit path %q", socket.Path)
+	}
+	for _, want := range []string{"ListenStream=/home/dev/.claude/ccstatus.sock", "SocketMode=0600", "WantedBy=sockets.target"} {
+		if !strings.Contains(socket.Content, want) {
+			t.Fatalf("expected %q in socket unit:\n%s", want, socket.Content)
+		}
+	}
+
+	if svc.Path != "/home/dev/.config/systemd/user/ccstatus.service" {
+		t.Fatalf("unexpected service unit path %q", svc.Path)
+	}
+	for _, want := range []string{"Requires=ccstatus.socket", "ExecStart=/usr/local/bin/ccstatus daemon\n", "WantedBy=default.target"} {
+		if !strings.Contains(svc.Content, want) {
+			t.Fatalf("expected %q in service unit:\n%s", want, svc.Content)
+		}
+	}
+}
+
+func TestSystemdUnitsForCustomProfile(t *testing.T) {
+	opts := linuxOptions()
+	opts.ConfigHome = "/xdg"
+	opts.ConfigDir = "/home/dev/Claude Work"
+	opts.SocketPath = "/home/dev/Claude Work/ccstatus.sock"
+
+	files, err := Files(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := opts.InstanceName()
+	if name == Name || !strings.HasPrefix(name, Name+"-") {
+		t.Fatalf("expected a profile-specific unit name, got %q", name)
+	}
+	if files[0].Path != "/xdg/systemd/user/"+name+".socket" {
+		t.Fatalf("expected units under XDG_CONFIG_HOME, got %q", files[0].Path)
+	}
+	if want := `ExecStart=/usr/local/bin/ccstatus daemon --config-dir "/home/dev/Claude Work"`; !strings.Contains(files[1].Content, want) {
+		t.Fatalf("expected quoted config dir in:\n%s", files[1].Content)
+	}
+	if want := "Requires=" + name + ".socket"; !strings.Contains(files[1].Content, want) {
+		t.Fatalf("expected %q in service unit", want)
+	}
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	opts := Options{
+		GOOS:       "darwin",
+		Home:       "/Users/dev",
+		Executable: "/opt/homebrew/bin/ccstatus",
+		ConfigDir:  "/Users/dev/a&b",
+	}
+
+	files, err := Files(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected a single plist, got %d files", len(files))
+	}
+
+	plist := files[0]
+	if want := "/Users/dev/Library/LaunchAgents/" + opts.LaunchdLabel() + ".plist"; plist.Path != want {
+		t.Fatalf("expected plist at %q, got %q", want, plist.Path)
+	}
+	for _, want := range []string{
+		"<string>" + opts.LaunchdLabel() + "</string>",
+		"<string>/opt/homebrew/bin/ccstatus</string>",
+		"<string>daemon</string>",
+		"<string>/Users/dev/a&amp;b</string>",
+		"<key>RunAtLoad</key>",
+	} {
+		if !strings.Contains(plist.Content, want) {
+			t.Fatalf("expected %q in plist:\n%s", want, plist.Content)
+		}
+	}
+}
+
+func TestReplacingRestartsTheLoadedService(t *testing.T) {
+	linux := linuxOptions()
+	fresh := ActivateCommands(linux, false)
+	replaced := ActivateCommands(linux, true)
+	if len(replaced) != len(fresh)+1 || strings.Join(replaced[len(replaced)-1], " ") != "systemctl --user restart ccstatus.service" {
+		t.Fatalf("expected a restart after reinstalling the units, got %v", replaced)
+	}
+	if len(ReplaceCommands(linux)) != 0 {
+		t.Fatal("expected no unload step for systemd")
+	}
+
+	darwin := Options{GOOS: "darwin", Home: "/Users/dev", Executable: "/usr/local/bin/ccstatus"}
+	replace := ReplaceCommands(darwin)
+	if len(replace) != 1 || replace[0][1] != "bootout" || !strings.HasSuffix(replace[0][2], "/"+darwin.LaunchdLabel()) {
+		t.Fatalf("expected the old job to be booted out, got %v", replace)
+	}
+	if activate := ActivateCommands(darwin, true); len(activate) != 1 || activate[0][1] != "bootstrap" {
+		t.Fatalf("expected only a bootstrap, got %v", activate)
+	}
+}
+
+func TestFilesRejectsUnsupportedPlatform(t *testing.T) {
+	if _, err := Files(Options{GOOS: "windows"}); err == nil {
+		t.Fatal("expected an error for an unsupported platform")
+	}
+}
+
+func TestChangedAndBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "unit", "ccstatus.service")
+	f := File{Path: path, Content: "new\n"}
+
+	if changed, existing := Changed(f); !changed || existing {
+		t.Fatalf("expected missing file to be changed and not existing, got %v %v", changed, existing)
+	}
+
+	if err := Write(File{Path: path, Content: "old\n"}); err != nil {
+		t.Fatal(err)
+	}
+	if changed, existing := Changed(f); !changed || !existing {
+		t.Fatalf("expected differing file to need a backup, got %v %v", changed, existing)
+	}
+
+	backupPath, err := Backup(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(backupPath); err != nil || string(data) != "old\n" {
+		t.Fatalf("expected backup of the old file, got %q (%v)", data, err)
+	}
+
+	if err := Write(f); err != nil {
+		t.Fatal(err)
+	}
+	if changed, _ := Changed(f); changed {
+		t.Fatal("expected written file to be up to date")
+	}
+}
@@ -0,0 +1,150 @@
+package statusline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"ccstatus/internal/config"
+)
+
+// claudeState is the part of Claude Code's .claude.json that identifies the account
+type claudeState struct {
+	OAuthAccount struct {
+		AccountUUID string `json:"accountUuid"`
+	} `json:"oauthAccount"`
+}
+
+// loginTokens holds the access tokens read from Claude Code's own credential
+// stores in this process. Only those belong to the account recorded in
+// .claude.json; env and command tokens may be for any account.
+var loginTokens sync.Map
+
+// claudeAccount memoizes the account UUID by the file's size and mtime, so
+// .claude.json, which can be several MB, is parsed once per run
+var claudeAccount struct {
+	sync.Mutex
+	path    string
+	size    int64
+	modTime time.Time
+	id      string
+}
+
+// credentialAccount is the account whose credentials were read last. It is
+// kept in the cache file so renders can pick their cache entry without
+// reading credentials, which may mean a keychain lookup or a slow command.
+type credentialAccount struct {
+	// Provider is the name of the provider that supplied the credentials
+	Provider string `json:"provider"`
+	// Account is the cache key computed from them
+	Account string `json:"account"`
+}
+
+// isClaudeLogin reports whether the named provider reads the credentials
+// Claude Code stored at login, as opposed to a token supplied by the user
+func isClaudeLogin(provider string) bool {
+	switch provider {
+	case ProviderKeychain, ProviderSecretService, ProviderFile:
+		return true
+	}
+	return false
+}
+
+// rememberCredentials notes that provider supplied creds, or that no
+// provider did when creds is nil, for renders that do not read credentials
+func rememberCredentials(creds *Credentials, provider CredentialProvider) {
+	var current credentialAccount
+	if creds != nil {
+		token := creds.ClaudeAiOauth.AccessToken
+		if token != "" && isClaudeLogin(provider.Name()) {
+			loginTokens.Store(token, struct{}{})
+		}
+		current = credentialAccount{Provider: provider.Name(), Account: accountKey(token)}
+	}
+	writeCurrentAccount(current)
+}
+
+// storedAccount returns the cache key recorded when credentials were last
+// read, without reading them again; empty when none were found. A Claude
+// Code login follows the account UUID in .claude.json, so switching logins
+// takes effect on the next render.
+func storedAccount() string {
+	current := readStore().Current
+	if current == nil {
+		return ""
+	}
+	if isClaudeLogin(current.Provider) {
+		if uuid := claudeAccountID(); uuid != "" {
+			return fingerprint(uuid)
+		}
+	}
+	return current.Account
+}
+
+// accountKey returns a non-reversible fingerprint of the account that owns
+// token. Claude Code's account UUID is preferred because it survives token
+// rotation, but only for tokens read from Claude Code's credential stores;
+// any other token is fingerprinted itself.
+func accountKey(token string) string {
+	if token == "" {
+		return ""
+	}
+
+	if _, ok := loginTokens.Load(token); ok {
+		if uuid := claudeAccountID(); uuid != "" {
+			return fingerprint(uuid)
+		}
+	}
+	return fingerprint(token)
+}
+
+// fingerprint hashes an account UUID or token into a cache key
+func fingerprint(id string) string {
+	sum := sha256.Sum256([]byte("ccstatus-account:" + id))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// CurrentAccount returns the cache key for the signed-in account without
+// touching the network. The credentials are always read, since only the
+// provider that supplies them tells whether .claude.json applies; renders
+// use storedAccount instead.
+func CurrentAccount(cfg *config.CCStatusConfig) string {
+	creds, _, err := LoadCredentials(cfg)
+	if err != nil {
+		return ""
+	}
+	return accountKey(creds.ClaudeAiOauth.AccessToken)
+}
+
+// claudeAccountID returns the account UUID Claude Code recorded at login
+func claudeAccountID() string {
+	path, err := config.GetGlobalConfigPath()
+	if err != nil {
+		return ""
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+
+	claudeAccount.Lock()
+	defer claudeAccount.Unlock()
+	if claudeAccount.path == path && claudeAccount.size == info.Size() && claudeAccount.modTime.Equal(info.ModTime()) {
+		return claudeAccount.id
+	}
+
+	var state claudeState
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &state)
+	}
+
+	claudeAccount.path = path
+	claudeAccount.size = info.Size()
+	claudeAccount.modTime = info.ModTime()
+	claudeAccount.id = state.OAuthAccount.AccountUUID
+	return claudeAccount.id
+}
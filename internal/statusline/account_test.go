@@ -0,0 +1,213 @@
+package statusline
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ccstatus/internal/config"
+)
+
+// writeClaudeAccount records accountUUID as the signed-in account in home/.claude.json
+func writeClaudeAccount(t *testing.T, home, accountUUID string) {
+	t.Helper()
+
+	data := []byte(`{"oauthAccount":{"accountUuid":"` + accountUUID + `"}}`)
+	if err := os.WriteFile(filepath.Join(home, config.GlobalConfigFile), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Rewrites within one test can keep the same size and mtime
+	claudeAccount.Lock()
+	claudeAccount.path = ""
+	claudeAccount.Unlock()
+}
+
+// writeLoginToken stores token in home/.claude/.credentials.json and returns
+// a config limited to the file provider and the env token
+func writeLoginToken(t *testing.T, home, token string) *config.CCStatusConfig {
+	t.Helper()
+
+	dir := filepath.Join(home, config.ConfigDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(`{"claudeAiOauth":{"accessToken":"` + token + `"}}`)
+	if err := os.WriteFile(filepath.Join(dir, credentialsFile), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultCCStatusConfig()
+	cfg.CredentialProviders = []string{ProviderFile, ProviderEnv}
+	return cfg
+}
+
+func TestAccountKeyPrefersAccountUUID(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	cfg := writeLoginToken(t, home, "token-a")
+	tokenKey := CurrentAccount(cfg)
+	if tokenKey == "" || tokenKey != accountKey("token-a") {
+		t.Fatal("expected a stable fingerprint per token without a recorded account")
+	}
+
+	writeClaudeAccount(t, home, "account-1")
+	first := CurrentAccount(cfg)
+	if first == tokenKey {
+		t.Fatal("expected the account UUID to take precedence over the token")
+	}
+
+	writeLoginToken(t, home, "token-b")
+	if got := CurrentAccount(cfg); got != first {
+		t.Fatalf("expected token rotation to keep the same account key, got %q", got)
+	}
+}
+
+func TestAccountKeyIgnoresAccountUUIDForSuppliedTokens(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeClaudeAccount(t, home, "account-1")
+	login := CurrentAccount(writeLoginToken(t, home, "token-a"))
+
+	// A token from the environment may belong to any account
+	t.Setenv(OAuthTokenEnv, "token-env")
+	cfg := config.DefaultCCStatusConfig()
+	cfg.CredentialProviders = []string{ProviderEnv}
+
+	env := CurrentAccount(cfg)
+	if env == login {
+		t.Fatal("expected an env token not to take the signed-in account's key")
+	}
+	token, err := GetAccessToken(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accountKey(token) != env {
+		t.Fatal("expected the fetch key to match CurrentAccount")
+	}
+}
+
+func TestStoredAccountFollowsCredentialReads(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if got := storedAccount(); got != "" {
+		t.Fatalf("expected no stored account before credentials are read, got %q", got)
+	}
+
+	cfg := writeLoginToken(t, home, "token-a")
+	want := CurrentAccount(cfg)
+	if got := storedAccount(); got != want || got == "" {
+		t.Fatalf("expected the login token's key to be stored, got %q", got)
+	}
+
+	// A login switch shows up without reading the credentials again
+	writeClaudeAccount(t, home, "account-1")
+	if got := storedAccount(); got != CurrentAccount(cfg) {
+		t.Fatalf("expected the stored key to follow the account UUID, got %q", got)
+	}
+
+	t.Setenv(OAuthTokenEnv, "token-env")
+	cfg.CredentialProviders = []string{ProviderEnv}
+	want = CurrentAccount(cfg)
+	if got := storedAccount(); got != want || got != accountKey("token-env") {
+		t.Fatalf("expected the env token's own key to be stored, got %q", got)
+	}
+}
+
+func TestRunFromCacheDoesNotReadCredentials(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	stubSpawn := spawnRefresher
+	spawnRefresher = func(string) error { return nil }
+	t.Cleanup(func() { spawnRefresher = stubSpawn })
+
+	marker := filepath.Join(home, "credential-command-ran")
+	cfg := config.DefaultCCStatusConfig()
+	cfg.CredentialProviders = []string{ProviderCommand}
+	cfg.CredentialCommand = "touch " + marker + "; echo token"
+	account := CurrentAccount(cfg)
+	if err := os.Remove(marker); err != nil {
+		t.Fatal(err)
+	}
+
+	usage := &UsageResponse{}
+	usage.FiveHour.Utilization = 42
+	saveCache(account, usage)
+
+	output := captureOutput(t, func() { runFromCache("Opus", "", cfg, "") })
+	if !strings.Contains(output, "Session: 42%") {
+		t.Fatalf("expected the stored account's usage, got %q", output)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("expected the render not to run the credential command")
+	}
+}
+
+func TestCacheKeepsAccountsApart(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	cfg := writeLoginToken(t, home, "token")
+
+	writeClaudeAccount(t, home, "account-1")
+	first := CurrentAccount(cfg)
+	usage := &UsageResponse{}
+	usage.FiveHour.Utilization = 80
+	saveCache(first, usage)
+
+	// Switching logins must not show the first account's usage
+	writeClaudeAccount(t, home, "account-2")
+	second := CurrentAccount(cfg)
+	if _, ok := loadStaleCache(second); ok {
+		t.Fatal("expected no cached usage for the new account")
+	}
+
+	usage.FiveHour.Utilization = 5
+	saveCache(second, usage)
+
+	cached, ok := loadCache(cfg, first)
+	if !ok || cached.FiveHour.Utilization != 80 {
+		t.Fatalf("expected first account entry to be kept, got %+v", cached)
+	}
+}
+
+func TestCacheDropsUnversionedEntries(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// Version 1 files stored a single entry without an account
+	legacy := `{"usage":{"five_hour":{"utilization":99}},"fetched_at":"` + time.Now().Format(time.RFC3339) + `"}`
+	path := filepath.Join(home, config.ConfigDir, cacheFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(legacy), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := loadStaleCache(accountKey("token")); ok {
+		t.Fatal("expected unattributed legacy cache to be ignored")
+	}
+
+	saveCache(accountKey("token"), &UsageResponse{})
+	if store := readStore(); store.Version != cacheVersion || len(store.Accounts) != 1 {
+		t.Fatalf("expected a migrated store with one account, got %+v", store)
+	}
+}
+
+func TestWriteCachePrunesForgottenAccounts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	writeCache("old", &cachedUsage{FetchedAt: time.Now().Add(-accountRetention - time.Hour)})
+	writeCache("recent", &cachedUsage{FetchedAt: time.Now().Add(-time.Hour)})
+	saveCache("current", &UsageResponse{})
+
+	store := readStore()
+	if _, ok := store.Accounts["old"]; ok {
+		t.Fatal("expected entry past retention to be pruned")
+	}
+	if _, ok := store.Accounts["recent"]; !ok {
+		t.Fatal("expected recent entry to be kept")
+	}
+}
@@ -0,0 +1,65 @@
+package statusline
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"ccstatus/internal/api"
+	"ccstatus/internal/config"
+)
+
+// workingDaysLeft returns how many working days remain between now and
+// reset in now's time zone, counting the rest of today and the part of the
+// reset day before the reset. Days in nonWorking are skipped.
+func workingDaysLeft(now, reset time.Time, nonWorking map[time.Weekday]bool) float64 {
+	var days float64
+	for t := now; t.Before(reset); {
+		next := time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		if next.After(reset) {
+			next = reset
+		}
+		if !nonWorking[t.Weekday()] {
+			days += next.Sub(t).Hours() / 24
+		}
+		t = next
+	}
+	return days
+}
+
+// dailyBudget returns the weekly utilization that can be spent per remaining
+// working day. With less than a day left the whole remainder is today's
+// budget. ok is false when the reset time is unknown or no working day is left.
+func dailyBudget(window api.UsageWindow, nonWorking map[time.Weekday]bool, now time.Time) (budget, days float64, ok bool) {
+	reset, ok := parseTimestamp(window.ResetsAt)
+	if !ok || !reset.After(now) {
+		return 0, 0, false
+	}
+
+	days = workingDaysLeft(now, reset, nonWorking)
+	if days <= 0 {
+		return 0, 0, false
+	}
+
+	left := math.Max(100-window.Utilization, 0)
+	return left / math.Max(days, 1), days, true
+}
+
+// weeklyBudgetLabel returns the statusline daily budget (e.g. "≈9%/day left"),
+// or "" when it is off or unavailable
+func weeklyBudgetLabel(cfg *config.CCStatusConfig, window api.UsageWindow, now time.Time) string {
+	if !cfg.ShowDailyBudget {
+		return ""
+	}
+	nonWorking, _ := config.ParseWeekdays(cfg.NonWorkingDays)
+	budget, _, ok := dailyBudget(window, nonWorking, now)
+	if !ok {
+		return ""
+	}
+	return FormatDailyBudget(budget)
+}
+
+// FormatDailyBudget formats a per-day budget (e.g. "≈9%/day left")
+func FormatDailyBudget(budget float64) string {
+	return fmt.Sprintf("≈%d%%/day left", int(math.Round(budget)))
+}
@@ -0,0 +1,108 @@
+package statusline
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"ccstatus/internal/api"
+	"ccstatus/internal/config"
+)
+
+func TestWorkingDaysLeftSkipsNonWorkingDays(t *testing.T) {
+	// Wednesday noon to the following Monday noon
+	now := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+	reset := time.Date(2026, 3, 16, 12, 0, 0, 0, time.UTC)
+
+	if got := workingDaysLeft(now, reset, nil); math.Abs(got-5) > 1e-9 {
+		t.Fatalf("expected 5 days, got %v", got)
+	}
+
+	weekend := map[time.Weekday]bool{time.Saturday: true, time.Sunday: true}
+	if got := workingDaysLeft(now, reset, weekend); math.Abs(got-3) > 1e-9 {
+		t.Fatalf("expected 3 working days, got %v", got)
+	}
+}
+
+func TestDailyBudget(t *testing.T) {
+	now := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+	window := api.UsageWindow{Utilization: 62, ResetsAt: time.Date(2026, 3, 16, 12, 0, 0, 0, time.UTC).Format(time.RFC3339)}
+	weekend := map[time.Weekday]bool{time.Saturday: true, time.Sunday: true}
+
+	budget, days, ok := dailyBudget(window, weekend, now)
+	if !ok || math.Abs(budget-38.0/3) > 1e-9 || math.Abs(days-3) > 1e-9 {
+		t.Fatalf("expected 38%% over 3 days, got %v over %v (ok=%v)", budget, days, ok)
+	}
+
+	// Less than a day left: the whole remainder is today's budget
+	window.ResetsAt = now.Add(6 * time.Hour).Format(time.RFC3339)
+	if budget, _, ok := dailyBudget(window, nil, now); !ok || budget != 38 {
+		t.Fatalf("expected 38%% for the last day, got %v (ok=%v)", budget, ok)
+	}
+
+	// Only non-working days left before the reset
+	saturday := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	window.ResetsAt = saturday.Add(30 * time.Hour).Format(time.RFC3339)
+	if _, _, ok := dailyBudget(window, weekend, saturday); ok {
+		t.Fatal("expected no budget over a weekend")
+	}
+
+	window.ResetsAt = ""
+	if _, _, ok := dailyBudget(window, nil, now); ok {
+		t.Fatal("expected no budget without a reset time")
+	}
+}
+
+func TestRenderStatusLineShowsDailyBudget(t *testing.T) {
+	usage := &UsageResponse{}
+	usage.SevenDay.Utilization = 62
+	usage.SevenDay.ResetsAt = time.Now().Add(4*24*time.Hour + time.Hour).Format(time.RFC3339)
+
+	cfg := config.DefaultCCStatusConfig()
+	cfg.ShowResetTimes = false
+	cfg.ShowSessionUsage = false
+	cfg.ShowDailyBudget = true
+
+	output := captureOutput(t, func() { renderStatusLine("Opus", usage, cfg, false, nil) })
+	if !strings.HasSuffix(output, "Week: 62% (≈9%/day left)") {
+		t.Fatalf("expected daily budget, got %q", output)
+	}
+
+	cfg.ShowDailyBudget = false
+	output = captureOutput(t, func() { renderStatusLine("Opus", usage, cfg, false, nil) })
+	if strings.Contains(output, "/day") {
+		t.Fatalf("expected no budget when disabled, got %q", output)
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	now := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+
+	usage := &UsageResponse{}
+	usage.FiveHour.Utilization = 60
+	usage.FiveHour.ResetsAt = now.Add(time.Hour).Format(time.RFC3339)
+	usage.SevenDay.Utilization = 62
+	usage.SevenDay.ResetsAt = time.Date(2026, 3, 16, 12, 0, 0, 0, time.UTC).Format(time.RFC3339)
+
+	cfg := config.DefaultCCStatusConfig()
+	cfg.NonWorkingDays = []string{"sun", "saturday", "someday"}
+
+	report := NewReport(cfg, "account", usage, now)
+	if report.Session.Pace != "behind" || report.Session.Estimated {
+		t.Fatalf("unexpected session report %+v", report.Session)
+	}
+	if !report.HasDailyBudget || math.Abs(report.WorkingDaysLeft-3) > 1e-9 {
+		t.Fatalf("expected a budget over 3 working days, got %+v", report)
+	}
+	if len(report.NonWorkingDays) != 2 || report.NonWorkingDays[0] != time.Sunday || report.NonWorkingDays[1] != time.Saturday {
+		t.Fatalf("unexpected non-working days %v", report.NonWorkingDays)
+	}
+	if len(report.InvalidDays) != 1 || report.InvalidDays[0] != "someday" {
+		t.Fatalf("unexpected invalid days %v", report.InvalidDays)
+	}
+	if report.Projection != nil {
+		t.Fatalf("expected no projection without history, got %+v", report.Projection)
+	}
+}
@@ -2,89 +2,369 @@ package statusline
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"time"
 
+	"ccstatus/internal/api"
 	"ccstatus/internal/config"
+	"ccstatus/internal/history"
 )
 
 const (
-	cacheFile  = "ccstatus-cache.json"
-	defaultTTL = 5 * time.Minute
+	cacheFile     = "ccstatus-cache.json"
+	fetchLockFile = "ccstatus-fetch.lock"
+	defaultTTL    = 5 * time.Minute
+
+	// defaultMaxStale is how old cached usage may be before it is no longer shown
+	defaultMaxStale = 24 * time.Hour
+
+	// cacheVersion is the current cache file schema. Version 1 stored a
+	// single cachedUsage without knowing which account it belonged to.
+	cacheVersion = 2
+	// accountRetention drops entries for accounts not seen for this long
+	accountRetention = 30 * 24 * time.Hour
+
+	// fetchWait is how long a process waits for another process's fetch
+	// before falling back to the previous value
+	fetchWait = 3 * time.Second
+
+	// Backoff bounds applied after a failed fetch
+	minBackoff    = 30 * time.Second
+	maxBackoff    = 15 * time.Minute
+	maxRetryAfter = time.Hour
 )
 
+// cacheStore is the cache file: one entry per account, keyed by accountKey
+type cacheStore struct {
+	Version  int                     `json:"version"`
+	Accounts map[string]*cachedUsage `json:"accounts"`
+	// Current is the account whose credentials were read last
+	Current *credentialAccount `json:"current,omitempty"`
+}
+
 type cachedUsage struct {
 	Usage     UsageResponse `json:"usage"`
 	FetchedAt time.Time     `json:"fetched_at"`
+	LastError *FetchError   `json:"last_error,omitempty"`
+}
+
+// FetchError records the most recent failed usage fetch
+type FetchError struct {
+	Kind    api.ErrorKind `json:"kind"`
+	Message string        `json:"message"`
+	At      time.Time     `json:"at"`
+	// Failures counts consecutive failed fetches
+	Failures int `json:"failures"`
+	// RetryAt is the backoff deadline; no request is sent before it
+	RetryAt time.Time `json:"retry_at,omitempty"`
+}
+
+// backoffError is returned instead of a request while the circuit is open
+func (e *FetchError) backoffError() error {
+	return &api.Error{
+		Kind:    e.Kind,
+		Message: "backing off until " + e.RetryAt.Local().Format(time.Kitchen),
+	}
 }
 
+// getCachePath returns the cache path inside the active config directory,
+// so every Claude Code profile keeps its own cached usage
 func getCachePath() (string, error) {
-	home, err := os.UserHomeDir()
+	dir, err := config.GetConfigDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(home, config.ConfigDir, cacheFile), nil
+	return filepath.Join(dir, cacheFile), nil
 }
 
-func readCache() (*cachedUsage, bool) {
+// getFetchLockPath returns the lock held while a process fetches usage
+func getFetchLockPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fetchLockFile), nil
+}
+
+// CacheStatus describes the cache entry of one account
+type CacheStatus struct {
+	// Path is the cache file
+	Path string
+	// Account is the fingerprint the entry is keyed by
+	Account string
+	// Usage is the cached usage; nil when nothing has been fetched yet
+	Usage     *UsageResponse
+	FetchedAt time.Time
+	// TTL is how long the entry is reused after FetchedAt
+	TTL       time.Duration
+	LastError *FetchError
+}
+
+// Fresh reports whether the entry is still within its TTL
+func (s *CacheStatus) Fresh() bool {
+	return s.Usage != nil && time.Since(s.FetchedAt) <= s.TTL
+}
+
+// Remaining returns how much of the TTL is left, or 0 once it has expired
+func (s *CacheStatus) Remaining() time.Duration {
+	if s.Usage == nil {
+		return 0
+	}
+	return max(s.TTL-time.Since(s.FetchedAt), 0)
+}
+
+// GetCacheStatus returns the cache entry of account
+func GetCacheStatus(cfg *config.CCStatusConfig, account string) (*CacheStatus, error) {
 	path, err := getCachePath()
 	if err != nil {
-		return nil, false
+		return nil, err
+	}
+
+	status := &CacheStatus{Path: path, Account: account}
+	cached, ok := readCache(account)
+	if !ok {
+		return status, nil
+	}
+
+	status.LastError = cached.LastError
+	if !cached.FetchedAt.IsZero() {
+		status.Usage = &cached.Usage
+		status.FetchedAt = cached.FetchedAt
+		status.TTL = cacheTTL(cfg, &cached.Usage, cached.FetchedAt)
+	}
+	return status, nil
+}
+
+// ClearCache removes the cache file, including every account's entry and
+// any recorded error. A missing file is not an error.
+func ClearCache() error {
+	path, err := getCachePath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot remove cache file: %w", err)
+	}
+	return nil
+}
+
+// readStore reads the cache file, migrating older schemas. A missing or
+// unreadable file yields an empty store.
+func readStore() *cacheStore {
+	store := &cacheStore{Version: cacheVersion, Accounts: map[string]*cachedUsage{}}
+
+	path, err := getCachePath()
+	if err != nil {
+		return store
 	}
 
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, false
+		return store
 	}
 
-	var cached cachedUsage
-	if err := json.Unmarshal(data, &cached); err != nil {
-		return nil, false
+	var onDisk cacheStore
+	if err := json.Unmarshal(data, &onDisk); err != nil {
+		return store
 	}
 
-	return &cached, true
+	return migrateStore(&onDisk)
 }
 
-func loadCache() (*UsageResponse, bool) {
-	cached, ok := readCache()
-	if !ok || time.Since(cached.FetchedAt) > defaultTTL {
+// migrateStore upgrades a store read from disk to cacheVersion
+func migrateStore(store *cacheStore) *cacheStore {
+	switch store.Version {
+	case cacheVersion:
+		if store.Accounts == nil {
+			store.Accounts = map[string]*cachedUsage{}
+		}
+		return store
+	default:
+		// Version 1 entries (no version field) cannot be attributed to an
+		// account, and newer versions are unknown, so start empty
+		return &cacheStore{Version: cacheVersion, Accounts: map[string]*cachedUsage{}}
+	}
+}
+
+// readCache returns the cache entry for account
+func readCache(account string) (*cachedUsage, bool) {
+	cached, ok := readStore().Accounts[account]
+	if !ok || cached == nil {
 		return nil, false
 	}
-	return &cached.Usage, true
+	return cached, true
 }
 
-func loadStaleCache() (*UsageResponse, bool) {
-	cached, ok := readCache()
-	if !ok {
+func loadCache(cfg *config.CCStatusConfig, account string) (*UsageResponse, bool) {
+	cached, ok := readCache(account)
+	if !ok || cached.FetchedAt.IsZero() {
+		return nil, false
+	}
+	if time.Since(cached.FetchedAt) > cacheTTL(cfg, &cached.Usage, cached.FetchedAt) {
 		return nil, false
 	}
 	return &cached.Usage, true
 }
 
-func saveCache(usage *UsageResponse) {
+// loadStaleCache returns the cached usage regardless of its age
+func loadStaleCache(account string) (*cachedUsage, bool) {
+	cached, ok := readCache(account)
+	if !ok || cached.FetchedAt.IsZero() {
+		return nil, false
+	}
+	return cached, true
+}
+
+func saveCache(account string, usage *UsageResponse) {
 	if usage == nil {
 		return
 	}
 
-	path, err := getCachePath()
+	writeCache(account, &cachedUsage{
+		Usage:     *usage,
+		FetchedAt: time.Now(),
+	})
+}
+
+// recordHistory appends a successful fetch to the usage history. History
+// is best-effort and never fails the fetch.
+func recordHistory(cfg *config.CCStatusConfig, account string, usage *UsageResponse) {
+	store, err := history.Open(cfg)
 	if err != nil {
 		return
 	}
+	_ = store.Append(history.NewRecord(time.Now(), account, usage))
+}
 
-	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+// recordFetchError stores err as the last fetch error, keeping any cached usage,
+// and opens the circuit until the backoff deadline
+func recordFetchError(account string, err error) {
+	cached, ok := readCache(account)
+	if !ok {
+		cached = &cachedUsage{}
+	}
+
+	failures := 1
+	if cached.LastError != nil {
+		failures = cached.LastError.Failures + 1
+	}
+
+	now := time.Now()
+	fetchErr := &FetchError{
+		Kind:     api.KindOf(err),
+		Message:  err.Error(),
+		At:       now,
+		Failures: failures,
+	}
+	if delay := backoffFor(err, failures); delay > 0 {
+		fetchErr.RetryAt = now.Add(delay)
+	}
+
+	cached.LastError = fetchErr
+	writeCache(account, cached)
+}
+
+// backoffFor returns how long to stop calling the API after the given
+// consecutive failure. A server-provided Retry-After wins; otherwise the
+// delay doubles from minBackoff up to maxBackoff. Auth errors are not backed
+// off, since signing in again fixes them immediately.
+func backoffFor(err error, failures int) time.Duration {
+	if api.KindOf(err) == api.KindAuth {
+		return 0
+	}
+
+	if retryAfter := api.RetryAfterOf(err); retryAfter > 0 {
+		return min(retryAfter, maxRetryAfter)
+	}
+
+	return exponentialBackoff(failures)
+}
+
+// exponentialBackoff doubles the delay from minBackoff with each consecutive
+// failure, up to maxBackoff
+func exponentialBackoff(failures int) time.Duration {
+	delay := minBackoff
+	for i := 1; i < failures && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// openCircuit returns the last error while its backoff deadline has not passed
+func openCircuit(account string) (*FetchError, bool) {
+	fetchErr, ok := LastFetchError(account)
+	if !ok || !time.Now().Before(fetchErr.RetryAt) {
+		return nil, false
+	}
+	return fetchErr, true
+}
+
+// LastFetchError returns the error recorded by the most recent failed fetch
+// for account. It is cleared by the next successful fetch.
+func LastFetchError(account string) (*FetchError, bool) {
+	cached, ok := readCache(account)
+	if !ok || cached.LastError == nil {
+		return nil, false
+	}
+	return cached.LastError, true
+}
+
+// writeCache stores cached as the entry for account, keeping the entries of
+// other accounts and dropping those not seen within accountRetention
+func writeCache(account string, cached *cachedUsage) {
+	store := readStore()
+	store.Accounts[account] = cached
+	for key, entry := range store.Accounts {
+		if key != account && time.Since(entry.lastSeen()) > accountRetention {
+			delete(store.Accounts, key)
+		}
+	}
+	writeStore(store)
+}
+
+// writeCurrentAccount records the account whose credentials were read last,
+// rewriting the cache file only when it changed
+func writeCurrentAccount(current credentialAccount) {
+	store := readStore()
+	if store.Current == nil && current == (credentialAccount{}) {
+		return
+	}
+	if store.Current != nil && *store.Current == current {
+		return
+	}
+	store.Current = &current
+	writeStore(store)
+}
+
+// writeStore replaces the cache file with store
+func writeStore(store *cacheStore) {
+	path, err := getCachePath()
+	if err != nil {
 		return
 	}
 
-	cached := cachedUsage{
-		Usage:     *usage,
-		FetchedAt: time.Now(),
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
 	}
 
-	data, err := json.Marshal(cached)
+	data, err := json.Marshal(store)
 	if err != nil {
 		return
 	}
 
-	_ = os.WriteFile(path, data, 0600)
+	// Write atomically so concurrent statusline processes never read a partial file
+	_ = config.WriteFileAtomic(path, data, 0600)
+}
+
+// lastSeen returns when the entry was last fetched or failed
+func (c *cachedUsage) lastSeen() time.Time {
+	if c == nil {
+		return time.Time{}
+	}
+	if c.LastError != nil && c.LastError.At.After(c.FetchedAt) {
+		return c.LastError.At
+	}
+	return c.FetchedAt
 }
@@ -0,0 +1,178 @@
+package statusline
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ccstatus/internal/api"
+	"ccstatus/internal/config"
+	"ccstatus/internal/filelock"
+)
+
+func TestConcurrentFetchesSendOneRequest(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte(`{"five_hour":{"utilization":33},"seven_day":{"utilization":44}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(api.BaseURLEnv, server.URL)
+	cfg := config.DefaultCCStatusConfig()
+
+	const workers = 16
+	var wg sync.WaitGroup
+	results := make(chan float64, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			usage, err := FetchUsage(cfg, "token", "")
+			if err != nil {
+				t.Errorf("expected every caller to get usage: %v", err)
+				return
+			}
+			results <- usage.FiveHour.Utilization
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Fatalf("expected a single request for %d concurrent fetches, got %d", workers, got)
+	}
+	for utilization := range results {
+		if utilization != 33 {
+			t.Fatalf("expected every caller to see the fetched value, got %v", utilization)
+		}
+	}
+}
+
+func TestConcurrentCacheWritesAreNeverSeenPartially(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	usage := &UsageResponse{}
+	usage.FiveHour.Utilization = 1
+	saveCache(accountKey("token"), usage)
+
+	stop := make(chan struct{})
+	var writers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		writers.Add(1)
+		go func(n int) {
+			defer writers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				u := &UsageResponse{}
+				u.FiveHour.Utilization = float64(n)
+				saveCache(accountKey("token"), u)
+				recordFetchError(accountKey("token"), &api.Error{Kind: api.KindServer, StatusCode: 503})
+			}
+		}(i)
+	}
+
+	for i := 0; i < 500; i++ {
+		if _, ok := readCache(accountKey("token")); !ok {
+			close(stop)
+			writers.Wait()
+			t.Fatalf("read %d saw a missing or partial cache file", i)
+		}
+	}
+
+	close(stop)
+	writers.Wait()
+}
+
+func TestRefreshUsageBypassesFreshCacheAndBackoff(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`{"five_hour":{"utilization":21},"seven_day":{"utilization":8}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(api.BaseURLEnv, server.URL)
+	cfg := config.DefaultCCStatusConfig()
+	account := accountKey("token")
+
+	saveCache(account, &UsageResponse{})
+	recordFetchError(account, &api.Error{Kind: api.KindServer, StatusCode: 503})
+	if _, open := openCircuit(account); !open {
+		t.Fatal("expected the recorded error to open the circuit")
+	}
+
+	usage, err := RefreshUsage(cfg, "token", "")
+	if err != nil {
+		t.Fatalf("expected forced refresh to fetch: %v", err)
+	}
+	if usage.FiveHour.Utilization != 21 || atomic.LoadInt32(&requests) != 1 {
+		t.Fatalf("expected one request returning fresh usage, got %+v", usage)
+	}
+
+	status, err := GetCacheStatus(cfg, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Fresh() || status.LastError != nil || status.Usage.FiveHour.Utilization != 21 {
+		t.Fatalf("expected a fresh entry without error, got %+v", status)
+	}
+	if remaining := status.Remaining(); remaining <= 0 || remaining > defaultTTL {
+		t.Fatalf("expected remaining TTL within %v, got %v", defaultTTL, remaining)
+	}
+}
+
+func TestRefreshUsageFailsWhileAnotherProcessFetches(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := config.DefaultCCStatusConfig()
+	account := accountKey("token")
+	saveCache(account, &UsageResponse{})
+
+	path, err := getFetchLockPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock, err := filelock.TryLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Unlock()
+
+	// The cached entry must not be passed off as a successful refresh
+	if usage, err := RefreshUsage(cfg, "token", ""); err == nil {
+		t.Fatalf("expected an error while the fetch lock is held, got %+v", usage)
+	}
+}
+
+func TestClearCacheRemovesEveryAccount(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := config.DefaultCCStatusConfig()
+
+	saveCache("a", &UsageResponse{})
+	saveCache("b", &UsageResponse{})
+
+	if err := ClearCache(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ClearCache(); err != nil {
+		t.Fatalf("expected clearing a missing cache to succeed: %v", err)
+	}
+
+	status, err := GetCacheStatus(cfg, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Usage != nil || status.Fresh() {
+		t.Fatalf("expected empty status after clear, got %+v", status)
+	}
+}
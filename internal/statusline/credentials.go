@@ -0,0 +1,352 @@
+package statusline
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ccstatus/internal/config"
+)
+
+const (
+	keychainService = "Claude Code-credentials"
+	credentialsFile = ".credentials.json"
+
+	// OAuthTokenEnv is the environment variable Claude Code reads a long-lived OAuth token from
+	OAuthTokenEnv = "CLAUDE_CODE_OAUTH_TOKEN"
+
+	commandTimeout = 10 * time.Second
+)
+
+// Credential provider names, as used in the credential_providers config key
+const (
+	ProviderKeychain      = "keychain"
+	ProviderSecretService = "secret-service"
+	ProviderFile          = "file"
+	ProviderEnv           = "env"
+	ProviderCommand       = "command"
+)
+
+// DefaultCredentialProviders is the lookup order used when none is configured
+var DefaultCredentialProviders = []string{
+	ProviderKeychain,
+	ProviderSecretService,
+	ProviderFile,
+	ProviderEnv,
+	ProviderCommand,
+}
+
+// Credentials represents the OAuth credentials stored by Claude Code
+type Credentials struct {
+	ClaudeAiOauth OAuthCredentials `json:"claudeAiOauth"`
+
+	// raw holds the original blob so unknown fields survive a write-back
+	raw []byte
+}
+
+// OAuthCredentials is the claudeAiOauth object inside the credentials blob
+type OAuthCredentials struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	// ExpiresAt is the access token expiry in Unix milliseconds
+	ExpiresAt int64 `json:"expiresAt,omitempty"`
+}
+
+// ExpiresAt returns when the access token expires, or the zero time if unknown
+func (c *Credentials) ExpiresAt() time.Time {
+	if c.ClaudeAiOauth.ExpiresAt == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(c.ClaudeAiOauth.ExpiresAt)
+}
+
+// CanRefresh reports whether the credentials carry a refresh token
+func (c *Credentials) CanRefresh() bool {
+	return c.ClaudeAiOauth.RefreshToken != ""
+}
+
+// marshal encodes the credentials back into the original blob,
+// preserving any fields ccstatus does not know about
+func (c *Credentials) marshal() ([]byte, error) {
+	blob := map[string]any{}
+	if len(c.raw) > 0 {
+		if err := json.Unmarshal(c.raw, &blob); err != nil {
+			blob = map[string]any{}
+		}
+	}
+
+	oauth, ok := blob["claudeAiOauth"].(map[string]any)
+	if !ok {
+		oauth = map[string]any{}
+	}
+	oauth["accessToken"] = c.ClaudeAiOauth.AccessToken
+	oauth["refreshToken"] = c.ClaudeAiOauth.RefreshToken
+	oauth["expiresAt"] = c.ClaudeAiOauth.ExpiresAt
+	blob["claudeAiOauth"] = oauth
+
+	return json.Marshal(blob)
+}
+
+// CredentialProvider retrieves OAuth credentials from a single source
+type CredentialProvider interface {
+	// Name returns the provider name used in the config file
+	Name() string
+	// Credentials returns the stored credentials, or an error if unavailable
+	Credentials() (*Credentials, error)
+}
+
+// CredentialWriter is implemented by providers that can store rotated credentials
+type CredentialWriter interface {
+	SaveCredentials(creds *Credentials) error
+}
+
+// NewCredentialProviders builds the providers configured in cfg, in lookup order.
+// The command provider is skipped when no credential_command is set.
+func NewCredentialProviders(cfg *config.CCStatusConfig) ([]CredentialProvider, error) {
+	names := cfg.CredentialProviders
+	if len(names) == 0 {
+		names = DefaultCredentialProviders
+	}
+
+	providers := make([]CredentialProvider, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case ProviderKeychain:
+			providers = append(providers, &keychainProvider{service: keychainServiceName()})
+		case ProviderSecretService:
+			providers = append(providers, &secretServiceProvider{service: keychainServiceName()})
+		case ProviderFile:
+			providers = append(providers, &fileProvider{})
+		case ProviderEnv:
+			providers = append(providers, &envProvider{variable: OAuthTokenEnv})
+		case ProviderCommand:
+			if cfg.CredentialCommand != "" {
+				providers = append(providers, &commandProvider{command: cfg.CredentialCommand})
+			}
+		default:
+			return nil, fmt.Errorf("unknown credential provider %q", name)
+		}
+	}
+
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no credential providers configured")
+	}
+
+	return providers, nil
+}
+
+// LoadCredentials tries each configured provider in order and returns the
+// first credentials found together with the provider that supplied them
+func LoadCredentials(cfg *config.CCStatusConfig) (*Credentials, CredentialProvider, error) {
+	providers, err := NewCredentialProviders(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var failures []string
+	for _, provider := range providers {
+		creds, err := provider.Credentials()
+		if err == nil {
+			rememberCredentials(creds, provider)
+			return creds, provider, nil
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", provider.Name(), err))
+	}
+
+	rememberCredentials(nil, nil)
+	return nil, nil, fmt.Errorf("%s", strings.Join(failures, "; "))
+}
+
+// GetAccessToken retrieves the OAuth token from the configured credential providers,
+// refreshing it first when it is about to expire
+func GetAccessToken(cfg *config.CCStatusConfig) (string, error) {
+	creds, provider, err := LoadCredentials(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	if NeedsRefresh(creds, time.Now()) && CanSaveCredentials(provider) {
+		// A failed refresh is not fatal: the current token may still be accepted
+		if refreshed, ok := refreshToken(cfg, provider); ok {
+			rememberCredentials(refreshed, provider)
+			return refreshed.ClaudeAiOauth.AccessToken, nil
+		}
+	}
+
+	return creds.ClaudeAiOauth.AccessToken, nil
+}
+
+// GetCredentialsFilePath returns the path to ~/.claude/.credentials.json
+func GetCredentialsFilePath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, credentialsFile), nil
+}
+
+// keychainServiceName returns the Keychain item name for the active profile.
+// Like Claude Code, a custom config directory gets a suffix derived from its path.
+func keychainServiceName() string {
+	custom := config.CustomConfigDir()
+	if custom == "" {
+		return keychainService
+	}
+	sum := sha256.Sum256([]byte(custom))
+	return keychainService + "-" + hex.EncodeToString(sum[:])[:8]
+}
+
+// keychainProvider reads the credentials blob from the macOS Keychain
+type keychainProvider struct {
+	service string
+}
+
+func (p *keychainProvider) Name() string { return ProviderKeychain }
+
+func (p *keychainProvider) Credentials() (*Credentials, error) {
+	cmd := exec.Command("security", "find-generic-password", "-s", p.service, "-w")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseCredentials(output)
+}
+
+// SaveCredentials updates the Keychain item in place. The blob is passed
+// hex-encoded on stdin so it never appears in the process list.
+func (p *keychainProvider) SaveCredentials(creds *Credentials) error {
+	data, err := creds.marshal()
+	if err != nil {
+		return err
+	}
+
+	account := os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		account = current.Username
+	}
+
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -a %q -s %q -X %s\n",
+		account, p.service, hex.EncodeToString(data)))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("cannot update keychain: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// fileProvider reads the credentials blob Claude Code writes on Linux
+type fileProvider struct{}
+
+func (p *fileProvider) Name() string { return ProviderFile }
+
+func (p *fileProvider) Credentials() (*Credentials, error) {
+	path, err := GetCredentialsFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("not found at %s", path)
+		}
+		return nil, err
+	}
+	return parseCredentials(data)
+}
+
+// SaveCredentials rewrites the credentials file atomically
+func (p *fileProvider) SaveCredentials(creds *Credentials) error {
+	path, err := GetCredentialsFilePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := creds.marshal()
+	if err != nil {
+		return err
+	}
+
+	if err := config.WriteFileAtomic(path, data, 0600); err != nil {
+		return fmt.Errorf("cannot write credentials file: %w", err)
+	}
+	return nil
+}
+
+// envProvider reads a bare access token from an environment variable
+type envProvider struct {
+	variable string
+}
+
+func (p *envProvider) Name() string { return ProviderEnv }
+
+func (p *envProvider) Credentials() (*Credentials, error) {
+	token := strings.TrimSpace(os.Getenv(p.variable))
+	if token == "" {
+		return nil, fmt.Errorf("%s is not set", p.variable)
+	}
+	return newTokenCredentials(token), nil
+}
+
+// commandProvider runs a helper command such as `pass` or `op read`.
+// The command may print either a claudeAiOauth JSON blob or a bare token.
+type commandProvider struct {
+	command string
+}
+
+func (p *commandProvider) Name() string { return ProviderCommand }
+
+func (p *commandProvider) Credentials() (*Credentials, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", p.command)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%q failed: %w", p.command, err)
+	}
+
+	trimmed := strings.TrimSpace(string(output))
+	if strings.HasPrefix(trimmed, "{") {
+		return parseCredentials([]byte(trimmed))
+	}
+	if trimmed == "" {
+		return nil, fmt.Errorf("%q printed no token", p.command)
+	}
+	return newTokenCredentials(trimmed), nil
+}
+
+// newTokenCredentials wraps a bare access token
+func newTokenCredentials(token string) *Credentials {
+	var creds Credentials
+	creds.ClaudeAiOauth.AccessToken = token
+	return &creds
+}
+
+// parseCredentials parses a claudeAiOauth JSON blob
+func parseCredentials(data []byte) (*Credentials, error) {
+	credsJSON := strings.TrimSpace(string(data))
+	if credsJSON == "" {
+		return nil, fmt.Errorf("empty credentials")
+	}
+
+	var creds Credentials
+	if err := json.Unmarshal([]byte(credsJSON), &creds); err != nil {
+		return nil, err
+	}
+
+	if creds.ClaudeAiOauth.AccessToken == "" {
+		return nil, fmt.Errorf("no access token in credentials")
+	}
+
+	creds.raw = []byte(credsJSON)
+	return &creds, nil
+}
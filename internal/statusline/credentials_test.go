@@ -0,0 +1,116 @@
+package statusline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ccstatus/internal/config"
+)
+
+func TestFileProviderReadsCredentialsFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, config.ConfigDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte(`{"claudeAiOauth":{"accessToken":"sk-ant-oat01-file","refreshToken":"r"}}`)
+	if err := os.WriteFile(filepath.Join(dir, credentialsFile), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err := (&fileProvider{}).Credentials()
+	if err != nil {
+		t.Fatalf("expected credentials file to be read: %v", err)
+	}
+	if got := creds.ClaudeAiOauth.AccessToken; got != "sk-ant-oat01-file" {
+		t.Fatalf("expected file token, got %q", got)
+	}
+}
+
+func TestFileProviderMissingFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if _, err := (&fileProvider{}).Credentials(); err == nil {
+		t.Fatal("expected error for missing credentials file")
+	}
+}
+
+func TestParseCredentialsRejectsEmptyToken(t *testing.T) {
+	if _, err := parseCredentials([]byte(`{"claudeAiOauth":{}}`)); err == nil {
+		t.Fatal("expected error for credentials without access token")
+	}
+	if _, err := parseCredentials([]byte("  ")); err == nil {
+		t.Fatal("expected error for empty credentials")
+	}
+}
+
+func TestLoadCredentialsFollowsConfiguredOrder(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(OAuthTokenEnv, "sk-ant-oat01-env")
+
+	cfg := config.DefaultCCStatusConfig()
+	cfg.CredentialProviders = []string{ProviderCommand, ProviderEnv}
+	cfg.CredentialCommand = "echo sk-ant-oat01-command"
+
+	creds, provider, err := LoadCredentials(cfg)
+	if err != nil {
+		t.Fatalf("expected credentials: %v", err)
+	}
+	if provider.Name() != ProviderCommand {
+		t.Fatalf("expected command provider first, got %q", provider.Name())
+	}
+	if got := creds.ClaudeAiOauth.AccessToken; got != "sk-ant-oat01-command" {
+		t.Fatalf("expected command token, got %q", got)
+	}
+
+	cfg.CredentialProviders = []string{ProviderFile, ProviderEnv, ProviderCommand}
+
+	creds, provider, err = LoadCredentials(cfg)
+	if err != nil {
+		t.Fatalf("expected credentials: %v", err)
+	}
+	if provider.Name() != ProviderEnv {
+		t.Fatalf("expected fallback to env provider, got %q", provider.Name())
+	}
+	if got := creds.ClaudeAiOauth.AccessToken; got != "sk-ant-oat01-env" {
+		t.Fatalf("expected env token, got %q", got)
+	}
+}
+
+func TestCommandProviderParsesCredentialsBlob(t *testing.T) {
+	provider := &commandProvider{command: `echo '{"claudeAiOauth":{"accessToken":"sk-ant-oat01-blob"}}'`}
+
+	creds, err := provider.Credentials()
+	if err != nil {
+		t.Fatalf("expected credentials: %v", err)
+	}
+	if got := creds.ClaudeAiOauth.AccessToken; got != "sk-ant-oat01-blob" {
+		t.Fatalf("expected blob token, got %q", got)
+	}
+}
+
+func TestNewCredentialProvidersRejectsUnknownName(t *testing.T) {
+	cfg := config.DefaultCCStatusConfig()
+	cfg.CredentialProviders = []string{"vault"}
+
+	if _, err := NewCredentialProviders(cfg); err == nil {
+		t.Fatal("expected error for unknown provider")
+	}
+}
+
+func TestKeychainServiceNameIsSuffixedForCustomConfigDir(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, "")
+	if got := keychainServiceName(); got != keychainService {
+		t.Fatalf("expected default service name, got %q", got)
+	}
+
+	t.Setenv(config.ConfigDirEnv, "/home/dev/.claude-work")
+	got := keychainServiceName()
+	if got == keychainService || len(got) != len(keychainService)+9 {
+		t.Fatalf("expected hashed suffix on service name, got %q", got)
+	}
+}
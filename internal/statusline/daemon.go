@@ -0,0 +1,149 @@
+package statusline
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"ccstatus/internal/api"
+	"ccstatus/internal/config"
+	"ccstatus/internal/daemon"
+)
+
+// Bounds on how long the daemon sleeps between polls
+const (
+	daemonMinPoll = 10 * time.Second
+	daemonMaxPoll = maxTTL
+)
+
+// RunDaemon polls usage for the signed-in account and serves it on the
+// daemon socket until ctx is cancelled. Polls go through FetchUsage, so the
+// cache, backoff and fetch lock are shared with statusline processes.
+func RunDaemon(ctx context.Context, claudeCodeVersion string) error {
+	// Under systemd the socket unit owns the socket and passes it in
+	listener, err := daemon.Activated()
+	if err != nil {
+		return err
+	}
+
+	if listener == nil {
+		path, err := daemon.GetSocketPath()
+		if err != nil {
+			return err
+		}
+		if listener, err = daemon.Listen(path); err != nil {
+			return err
+		}
+	}
+	return serveDaemon(ctx, listener, claudeCodeVersion)
+}
+
+// serveDaemon runs the poll loop and answers queries on listener
+func serveDaemon(ctx context.Context, listener net.Listener, claudeCodeVersion string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	server := &daemon.Server{}
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(ctx, listener) }()
+
+	for {
+		snapshot, wait := pollUsage(claudeCodeVersion)
+		server.Update(snapshot)
+
+		select {
+		case <-ctx.Done():
+			return <-served
+		case err := <-served:
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// pollUsage fetches usage for the signed-in account and returns the snapshot
+// to serve together with how long to wait before the next poll
+func pollUsage(claudeCodeVersion string) (*daemon.Snapshot, time.Duration) {
+	// Reload on every poll so config changes apply without a restart
+	cfg, _ := config.LoadCCStatusConfig()
+
+	var account string
+	token, err := GetAccessToken(cfg)
+	if err != nil {
+		account = CurrentAccount(cfg)
+		recordFetchError(account, &api.Error{Kind: api.KindAuth, Err: err})
+	} else {
+		account = accountKey(token)
+		// Failures are recorded in the cache and picked up below
+		_, _ = FetchUsage(cfg, token, claudeCodeVersion)
+	}
+
+	now := time.Now()
+	snapshot := &daemon.Snapshot{Account: account}
+	wait := daemonMinPoll
+
+	cached, ok := readCache(account)
+	if !ok {
+		return snapshot, wait
+	}
+
+	if !cached.FetchedAt.IsZero() {
+		snapshot.Usage = &cached.Usage
+		snapshot.FetchedAt = cached.FetchedAt
+		snapshot.TTL = cacheTTL(cfg, &cached.Usage, cached.FetchedAt)
+		if snapshot.Fresh(now) {
+			wait = snapshot.TTL - now.Sub(cached.FetchedAt)
+		}
+	}
+
+	if fetchErr := cached.LastError; fetchErr != nil {
+		snapshot.ErrorKind = fetchErr.Kind
+		snapshot.ErrorMessage = fetchErr.Message
+		if fetchErr.RetryAt.After(now) {
+			wait = fetchErr.RetryAt.Sub(now)
+		} else if fetchErr.Kind == api.KindAuth {
+			// Renders retry auth failures at once, since signing in fixes
+			// them, but the daemon must not poll a signed-out account
+			// every few seconds
+			wait = exponentialBackoff(fetchErr.Failures)
+		}
+	}
+
+	return snapshot, min(max(wait, daemonMinPoll), daemonMaxPoll)
+}
+
+// queryDaemon returns the running daemon's snapshot. It is ignored when the
+// daemon serves a different account than the one the credentials were last
+// read for; credentials are not read here.
+func queryDaemon() (*daemon.Snapshot, bool) {
+	path, err := daemon.GetSocketPath()
+	if err != nil {
+		return nil, false
+	}
+
+	snapshot, err := daemon.Query(path)
+	if err != nil {
+		return nil, false
+	}
+
+	if account := storedAccount(); account != "" && snapshot.Account != account {
+		return nil, false
+	}
+	return snapshot, true
+}
+
+// printSnapshot renders a daemon snapshot like cached usage
+func printSnapshot(model, sessionID string, cfg *config.CCStatusConfig, snapshot *daemon.Snapshot) {
+	switch {
+	case snapshot.Fresh(time.Now()):
+		printStatusLine(model, snapshot.Account, sessionID, snapshot.Usage, cfg)
+	case snapshot.Usage != nil:
+		printStaleStatusLine(model, &cachedUsage{Usage: *snapshot.Usage, FetchedAt: snapshot.FetchedAt}, cfg)
+	default:
+		printFallback(model, cfg)
+	}
+
+	if snapshot.ErrorKind != "" {
+		printErrorIndicator(snapshot.ErrorKind)
+	}
+}
@@ -0,0 +1,104 @@
+package statusline
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ccstatus/internal/api"
+	"ccstatus/internal/config"
+	"ccstatus/internal/daemon"
+)
+
+func TestPollUsageServesFetchedUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"five_hour":{"utilization":37},"seven_day":{"utilization":12}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "")
+	t.Setenv(OAuthTokenEnv, "token")
+	t.Setenv(api.BaseURLEnv, server.URL)
+
+	snapshot, wait := pollUsage("")
+	if snapshot.Account != accountKey("token") {
+		t.Fatalf("expected snapshot for the token's account, got %q", snapshot.Account)
+	}
+	if !snapshot.Fresh(time.Now()) || snapshot.Usage.FiveHour.Utilization != 37 {
+		t.Fatalf("expected fresh usage, got %+v", snapshot)
+	}
+	if wait < defaultTTL-time.Second || wait > defaultTTL {
+		t.Fatalf("expected next poll after the TTL, got %v", wait)
+	}
+}
+
+func TestPollUsageWaitsForBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "")
+	t.Setenv(OAuthTokenEnv, "token")
+	t.Setenv(api.BaseURLEnv, server.URL)
+
+	snapshot, wait := pollUsage("")
+	if snapshot.ErrorKind != api.KindRateLimited || snapshot.Usage != nil {
+		t.Fatalf("expected a rate-limited snapshot without usage, got %+v", snapshot)
+	}
+	if wait < 9*time.Minute || wait > 10*time.Minute {
+		t.Fatalf("expected next poll at the Retry-After deadline, got %v", wait)
+	}
+}
+
+func TestPollUsageBacksOffAuthFailures(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "")
+	t.Setenv(OAuthTokenEnv, "token")
+	t.Setenv(api.BaseURLEnv, server.URL)
+
+	_, first := pollUsage("")
+	snapshot, second := pollUsage("")
+	if snapshot.ErrorKind != api.KindAuth {
+		t.Fatalf("expected an auth error snapshot, got %+v", snapshot)
+	}
+	if first != minBackoff || second != 2*minBackoff {
+		t.Fatalf("expected the wait to double from %v, got %v then %v", minBackoff, first, second)
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Fatalf("expected one request per poll, got %d", got)
+	}
+}
+
+func TestPrintSnapshot(t *testing.T) {
+	cfg := config.DefaultCCStatusConfig()
+	cfg.ShowResetTimes = false
+	cfg.ShowWeeklyUsage = false
+	cfg.ShowDataAge = true
+
+	usage := &UsageResponse{}
+	usage.FiveHour.Utilization = 37
+
+	fresh := &daemon.Snapshot{Usage: usage, FetchedAt: time.Now(), TTL: time.Minute}
+	if out := captureOutput(t, func() { printSnapshot("Opus", "", cfg, fresh) }); !strings.HasSuffix(out, "Session: 37%") {
+		t.Fatalf("expected fresh usage, got %q", out)
+	}
+
+	stale := &daemon.Snapshot{Usage: usage, FetchedAt: time.Now().Add(-10 * time.Minute), TTL: time.Minute, ErrorKind: api.KindOffline}
+	if out := captureOutput(t, func() { printSnapshot("Opus", "", cfg, stale) }); !strings.HasSuffix(out, "Session: 37% · 10m ago | ⚠ offline") {
+		t.Fatalf("expected stale usage with indicator, got %q", out)
+	}
+}
@@ -0,0 +1,101 @@
+package statusline
+
+import (
+	"time"
+
+	"ccstatus/internal/api"
+	"ccstatus/internal/config"
+
+	"github.com/fatih/color"
+)
+
+// paceTolerance is how many percentage points utilization may differ from
+// the elapsed share of the window and still count as on pace
+const paceTolerance = 10.0
+
+// pace compares utilization with how much of its window has elapsed
+type pace int
+
+const (
+	// paceUnknown means the window's reset time is unknown
+	paceUnknown pace = iota
+	paceBehind
+	paceOn
+	paceAhead
+)
+
+// String returns the pace name used in reports, or "" when unknown
+func (p pace) String() string {
+	switch p {
+	case paceBehind:
+		return "behind"
+	case paceOn:
+		return "on"
+	case paceAhead:
+		return "ahead"
+	}
+	return ""
+}
+
+// paceMarkers follow the percentage in pace mode
+var paceMarkers = map[pace]string{
+	paceBehind: "▼",
+	paceOn:     "≈",
+	paceAhead:  "▲",
+}
+
+// windowPace compares window's utilization with the share of its length
+// that has elapsed at now. At 60% with an hour left of a five-hour window
+// usage is behind pace; at 35% twenty minutes in it is ahead.
+func windowPace(window api.UsageWindow, length time.Duration, now time.Time) pace {
+	reset, ok := parseTimestamp(window.ResetsAt)
+	if !ok || !reset.After(now) {
+		return paceUnknown
+	}
+
+	remaining := reset.Sub(now)
+	if remaining > length {
+		remaining = length
+	}
+	expected := 100 * (1 - remaining.Hours()/length.Hours())
+
+	switch {
+	case window.Utilization > expected+paceTolerance:
+		return paceAhead
+	case window.Utilization < expected-paceTolerance:
+		return paceBehind
+	default:
+		return paceOn
+	}
+}
+
+// getPaceColor returns the color for a pace: red ahead, yellow on, green behind
+func getPaceColor(p pace) *color.Color {
+	switch p {
+	case paceAhead:
+		return redColor
+	case paceOn:
+		return yellowColor
+	default:
+		return greenColor
+	}
+}
+
+// windowColor returns the color of a usage value and its pace. Pace is only
+// computed in pace mode; otherwise, or when the reset time is unknown, the
+// absolute thresholds apply.
+func windowColor(cfg *config.CCStatusConfig, window api.UsageWindow, length time.Duration, now time.Time) (*color.Color, pace) {
+	if cfg.PaceMode && window.Utilization < 100 {
+		if p := windowPace(window, length, now); p != paceUnknown {
+			return getPaceColor(p), p
+		}
+	}
+	return getUsageColor(int(window.Utilization)), paceUnknown
+}
+
+// printPaceMarker prints the marker of a known pace after a usage value
+func printPaceMarker(p pace, c *color.Color) {
+	if marker, ok := paceMarkers[p]; ok {
+		c.Print(marker)
+	}
+}
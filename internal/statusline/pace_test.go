@@ -0,0 +1,79 @@
+package statusline
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ccstatus/internal/api"
+	"ccstatus/internal/config"
+)
+
+func TestWindowPace(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	window := func(utilization float64, remaining time.Duration) api.UsageWindow {
+		return api.UsageWindow{Utilization: utilization, ResetsAt: now.Add(remaining).Format(time.RFC3339)}
+	}
+
+	tests := []struct {
+		name   string
+		window api.UsageWindow
+		length time.Duration
+		want   pace
+	}{
+		{name: "high but late in the session", window: window(60, time.Hour), length: sessionWindow, want: paceBehind},
+		{name: "low but early in the session", window: window(35, 4*time.Hour+40*time.Minute), length: sessionWindow, want: paceAhead},
+		{name: "halfway at half", window: window(52, 150*time.Minute), length: sessionWindow, want: paceOn},
+		{name: "week on track", window: window(45, 4*24*time.Hour), length: weeklyWindow, want: paceOn},
+		{name: "unknown reset", window: api.UsageWindow{Utilization: 50}, length: sessionWindow, want: paceUnknown},
+		{name: "reset passed", window: window(50, -time.Minute), length: sessionWindow, want: paceUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := windowPace(tt.window, tt.length, now); got != tt.want {
+			t.Fatalf("%s: expected pace %d, got %d", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestWindowColorFollowsMode(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	window := api.UsageWindow{Utilization: 60, ResetsAt: now.Add(time.Hour).Format(time.RFC3339)}
+	cfg := config.DefaultCCStatusConfig()
+
+	if c, p := windowColor(cfg, window, sessionWindow, now); c != yellowColor || p != paceUnknown {
+		t.Fatalf("expected absolute yellow without pace, got pace %d", p)
+	}
+
+	cfg.PaceMode = true
+	if c, p := windowColor(cfg, window, sessionWindow, now); c != greenColor || p != paceBehind {
+		t.Fatalf("expected green behind pace, got pace %d", p)
+	}
+
+	// A reached limit stays red whatever the pace
+	window.Utilization = 100
+	if c, _ := windowColor(cfg, window, sessionWindow, now); c != redColor {
+		t.Fatal("expected red at the limit")
+	}
+}
+
+func TestRenderStatusLineMarksPace(t *testing.T) {
+	usage := &UsageResponse{}
+	usage.FiveHour.Utilization = 35
+	usage.FiveHour.ResetsAt = time.Now().Add(4*time.Hour + 40*time.Minute).Format(time.RFC3339)
+
+	cfg := config.DefaultCCStatusConfig()
+	cfg.ShowResetTimes = false
+	cfg.ShowWeeklyUsage = false
+	cfg.PaceMode = true
+
+	output := captureOutput(t, func() { renderStatusLine("Opus", usage, cfg, false, nil) })
+	if !strings.HasSuffix(output, "Session: 35%▲") {
+		t.Fatalf("expected ahead-of-pace marker, got %q", output)
+	}
+
+	output = captureOutput(t, func() { renderStatusLine("Opus", usage, cfg, true, nil) })
+	if !strings.HasSuffix(output, "Session: 35%") {
+		t.Fatalf("expected no pace marker on stale values, got %q", output)
+	}
+}
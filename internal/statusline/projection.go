@@ -0,0 +1,59 @@
+package statusline
+
+import (
+	"time"
+
+	"ccstatus/internal/config"
+	"ccstatus/internal/history"
+)
+
+// defaultProjectionLookback is how much recent history the burn rate is
+// computed from when projection_lookback is not set
+const defaultProjectionLookback = 30 * time.Minute
+
+// projectionLookback returns the configured burn rate lookback
+func projectionLookback(cfg *config.CCStatusConfig) time.Duration {
+	return config.ParseDuration(cfg.ProjectionLookback, defaultProjectionLookback)
+}
+
+// accountHistory returns the account's history records at or after since.
+// History is best-effort, so errors yield no records.
+func accountHistory(cfg *config.CCStatusConfig, account string, since time.Time) []history.Record {
+	if account == "" {
+		return nil
+	}
+	store, err := history.Open(cfg)
+	if err != nil {
+		return nil
+	}
+	records, err := store.RecordsSince(since)
+	if err != nil {
+		return nil
+	}
+	return history.Select(records, history.Filter{Account: account})
+}
+
+// projectSession predicts when the session window of usage reaches its limit
+// from the account's records. It returns nil when there is not enough
+// history yet.
+func projectSession(cfg *config.CCStatusConfig, records []history.Record, usage *UsageResponse, now time.Time) *history.Projection {
+	resetsAt, ok := parseTimestamp(usage.FiveHour.ResetsAt)
+	if !ok {
+		return nil
+	}
+
+	projection, ok := history.ProjectSession(records, resetsAt, now, projectionLookback(cfg))
+	if !ok {
+		return nil
+	}
+	return &projection
+}
+
+// printProjection prints the projection segment (e.g. " → 100% at 4:10pm")
+func printProjection(projection *history.Projection) {
+	if projection.Safe() {
+		dimColor.Print(" · safe until reset")
+		return
+	}
+	yellowColor.Printf(" → 100%% at %s", formatClock(projection.LimitAt))
+}
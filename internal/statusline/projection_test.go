@@ -0,0 +1,85 @@
+package statusline
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ccstatus/internal/config"
+	"ccstatus/internal/history"
+)
+
+func TestLoadSessionTrendUsesAccountHistory(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := config.DefaultCCStatusConfig()
+	cfg.ShowProjection = true
+	cfg.ProjectionLookback = "1h"
+
+	now := time.Now().Truncate(time.Minute)
+	usage := &UsageResponse{}
+	usage.FiveHour.ResetsAt = now.Add(3 * time.Hour).Format(time.RFC3339)
+
+	store, err := history.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, pct := range []float64{20, 30, 40} {
+		usage.FiveHour.Utilization = pct
+		at := now.Add(time.Duration(i-2) * 10 * time.Minute)
+		if err := store.Append(history.NewRecord(at, "mine", usage)); err != nil {
+			t.Fatal(err)
+		}
+		// Another account's flat usage must not affect the rate
+		if err := store.Append(history.NewRecord(at, "other", &UsageResponse{})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	trend := loadSessionTrend(cfg, "mine", "", usage, now)
+	if trend == nil || trend.projection == nil {
+		t.Fatal("expected a projection")
+	}
+	if want := now.Add(time.Hour); !trend.projection.LimitAt.Equal(want) {
+		t.Fatalf("expected limit at %v, got %v", want, trend.projection.LimitAt)
+	}
+	if trend.sparkline != nil {
+		t.Fatalf("expected no sparkline while it is off, got %v", trend.sparkline)
+	}
+
+	cfg.ShowSparkline = true
+	cfg.SparklineLength = 2
+	if trend := loadSessionTrend(cfg, "mine", "", usage, now); len(trend.sparkline) != 2 || trend.sparkline[0] != 30 || trend.sparkline[1] != 40 {
+		t.Fatalf("expected the last two session values, got %+v", trend)
+	}
+
+	cfg.ShowProjection = false
+	cfg.ShowSparkline = false
+	if trend := loadSessionTrend(cfg, "mine", "", usage, now); trend != nil {
+		t.Fatalf("expected no trend when disabled, got %+v", trend)
+	}
+}
+
+func TestRenderStatusLineShowsProjection(t *testing.T) {
+	usage := &UsageResponse{}
+	usage.FiveHour.Utilization = 40
+	usage.FiveHour.ResetsAt = time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	cfg := config.DefaultCCStatusConfig()
+	cfg.ShowResetTimes = false
+	cfg.ShowWeeklyUsage = false
+
+	limitAt := time.Date(2026, 3, 10, 16, 10, 0, 0, time.Local)
+	output := captureOutput(t, func() {
+		renderStatusLine("Opus", usage, cfg, false, &sessionTrend{projection: &history.Projection{Rate: 30, LimitAt: limitAt}})
+	})
+	if !strings.HasSuffix(output, "Session: 40% → 100% at 4:10pm") {
+		t.Fatalf("expected projected limit, got %q", output)
+	}
+
+	output = captureOutput(t, func() {
+		renderStatusLine("Opus", usage, cfg, false, &sessionTrend{projection: &history.Projection{Rate: 5}})
+	})
+	if !strings.HasSuffix(output, "Session: 40% · safe until reset") {
+		t.Fatalf("expected safe projection, got %q", output)
+	}
+}
@@ -0,0 +1,221 @@
+package statusline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ccstatus/internal/api"
+	"ccstatus/internal/config"
+	"ccstatus/internal/filelock"
+)
+
+const (
+	// DefaultTokenEndpoint is the OAuth token endpoint Claude Code refreshes against
+	DefaultTokenEndpoint = "https://console.anthropic.com/v1/oauth/token"
+	// DefaultOAuthClientID is the public OAuth client ID used by Claude Code
+	DefaultOAuthClientID = "9d1c250a-e61b-44d9-88ed-5944d1962f5e"
+
+	// refreshMargin refreshes tokens this long before they actually expire
+	refreshMargin = 5 * time.Minute
+
+	// tokenLockFile is held while a process refreshes the OAuth token, so
+	// concurrent processes never redeem the same refresh token twice
+	tokenLockFile = "ccstatus-token.lock"
+	// refreshErrorFile records the last failed token refresh and its backoff
+	refreshErrorFile = "ccstatus-token-refresh.json"
+	// tokenLockWait is how long a process waits for another process's
+	// refresh before using the current token
+	tokenLockWait = 3 * time.Second
+)
+
+// NeedsRefresh reports whether creds expire within the refresh margin and can be refreshed
+func NeedsRefresh(creds *Credentials, now time.Time) bool {
+	expiresAt := creds.ExpiresAt()
+	if expiresAt.IsZero() || !creds.CanRefresh() {
+		return false
+	}
+	return now.Add(refreshMargin).After(expiresAt)
+}
+
+// CanSaveCredentials reports whether provider can store rotated credentials.
+// Refresh tokens rotate on use, so credentials from a read-only provider
+// must never be refreshed: the copy in its store would stop working.
+func CanSaveCredentials(provider CredentialProvider) bool {
+	_, ok := provider.(CredentialWriter)
+	return ok
+}
+
+// RefreshAndSave refreshes creds in place and writes the rotated credentials
+// back to the provider they came from. Read-only providers are rejected
+// before the refresh token is redeemed.
+func RefreshAndSave(cfg *config.CCStatusConfig, creds *Credentials, provider CredentialProvider) error {
+	writer, ok := provider.(CredentialWriter)
+	if !ok {
+		return fmt.Errorf("%s credentials are read-only and cannot be refreshed", provider.Name())
+	}
+
+	if err := RefreshCredentials(cfg, creds); err != nil {
+		return err
+	}
+
+	if err := writer.SaveCredentials(creds); err != nil {
+		return fmt.Errorf("cannot save refreshed credentials to %s: %w", provider.Name(), err)
+	}
+	return nil
+}
+
+// refreshToken refreshes the provider's credentials while holding the token
+// lock and returns them. The credentials are read again under the lock, so a
+// refresh completed by another process is used instead of redeeming the
+// rotated refresh token a second time. Failures are backed off like failed
+// fetches; ok is false when no refreshed credentials are available.
+func refreshToken(cfg *config.CCStatusConfig, provider CredentialProvider) (*Credentials, bool) {
+	if _, open := refreshBackoff(); open {
+		return nil, false
+	}
+
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, false
+	}
+	lock, err := filelock.Acquire(filepath.Join(dir, tokenLockFile), tokenLockWait)
+	if err != nil {
+		return nil, false
+	}
+	defer lock.Unlock()
+
+	creds, err := provider.Credentials()
+	if err != nil {
+		return nil, false
+	}
+	if !NeedsRefresh(creds, time.Now()) {
+		return creds, true
+	}
+	// Another process may have failed while this one waited for the lock
+	if _, open := refreshBackoff(); open {
+		return nil, false
+	}
+
+	if err := RefreshAndSave(cfg, creds, provider); err != nil {
+		recordRefreshError(err)
+		return nil, false
+	}
+	clearRefreshError()
+	return creds, true
+}
+
+// getRefreshErrorPath returns the refresh error file inside the active config directory
+func getRefreshErrorPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, refreshErrorFile), nil
+}
+
+// LastRefreshError returns the error recorded by the most recent failed
+// token refresh. It is cleared by the next successful refresh.
+func LastRefreshError() (*FetchError, bool) {
+	path, err := getRefreshErrorPath()
+	if err != nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var refreshErr FetchError
+	if err := json.Unmarshal(data, &refreshErr); err != nil || refreshErr.At.IsZero() {
+		return nil, false
+	}
+	return &refreshErr, true
+}
+
+// refreshBackoff returns the last refresh error while its backoff deadline has not passed
+func refreshBackoff() (*FetchError, bool) {
+	refreshErr, ok := LastRefreshError()
+	if !ok || !time.Now().Before(refreshErr.RetryAt) {
+		return nil, false
+	}
+	return refreshErr, true
+}
+
+// recordRefreshError stores err with a backoff deadline. Unlike fetches,
+// rejected refresh tokens are backed off too: retrying an invalid grant on
+// every render would only stall the statusline.
+func recordRefreshError(err error) {
+	path, pathErr := getRefreshErrorPath()
+	if pathErr != nil {
+		return
+	}
+
+	failures := 1
+	if last, ok := LastRefreshError(); ok {
+		failures = last.Failures + 1
+	}
+
+	now := time.Now()
+	delay := exponentialBackoff(failures)
+	if retryAfter := api.RetryAfterOf(err); retryAfter > 0 {
+		delay = min(retryAfter, maxRetryAfter)
+	}
+
+	data, jsonErr := json.Marshal(&FetchError{
+		Kind:     api.KindOf(err),
+		Message:  err.Error(),
+		At:       now,
+		Failures: failures,
+		RetryAt:  now.Add(delay),
+	})
+	if jsonErr != nil {
+		return
+	}
+	_ = config.WriteFileAtomic(path, data, 0600)
+}
+
+// clearRefreshError removes the recorded refresh error
+func clearRefreshError() {
+	if path, err := getRefreshErrorPath(); err == nil {
+		_ = os.Remove(path)
+	}
+}
+
+// RefreshCredentials exchanges the refresh token for a new access token
+func RefreshCredentials(cfg *config.CCStatusConfig, creds *Credentials) error {
+	if !creds.CanRefresh() {
+		return fmt.Errorf("no refresh token available")
+	}
+
+	endpoint := cfg.TokenEndpoint
+	if endpoint == "" {
+		endpoint = DefaultTokenEndpoint
+	}
+	clientID := cfg.OAuthClientID
+	if clientID == "" {
+		clientID = DefaultOAuthClientID
+	}
+
+	client, err := api.NewFromConfig(cfg, "")
+	if err != nil {
+		return err
+	}
+
+	token, err := client.RefreshToken(context.Background(), endpoint, clientID, creds.ClaudeAiOauth.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	creds.ClaudeAiOauth.AccessToken = token.AccessToken
+	if token.RefreshToken != "" {
+		creds.ClaudeAiOauth.RefreshToken = token.RefreshToken
+	}
+	if token.ExpiresIn > 0 {
+		creds.ClaudeAiOauth.ExpiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second).UnixMilli()
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package statusline
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ccstatus/internal/config"
+)
+
+func TestNeedsRefresh(t *testing.T) {
+	now := time.Now()
+
+	creds := newTokenCredentials("access")
+	if NeedsRefresh(creds, now) {
+		t.Fatal("expected credentials without expiry not to need refresh")
+	}
+
+	creds.ClaudeAiOauth.RefreshToken = "refresh"
+	creds.ClaudeAiOauth.ExpiresAt = now.Add(time.Hour).UnixMilli()
+	if NeedsRefresh(creds, now) {
+		t.Fatal("expected token valid for an hour not to need refresh")
+	}
+
+	creds.ClaudeAiOauth.ExpiresAt = now.Add(time.Minute).UnixMilli()
+	if !NeedsRefresh(creds, now) {
+		t.Fatal("expected token expiring within the margin to need refresh")
+	}
+}
+
+func TestRefreshAndSaveWritesBackToCredentialsFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("cannot decode refresh request: %v", err)
+		}
+		if body["grant_type"] != "refresh_token" || body["refresh_token"] != "old-refresh" {
+			t.Errorf("unexpected refresh request: %v", body)
+		}
+		w.Write([]byte(`{"access_token":"new-access","refresh_token":"new-refresh","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, config.ConfigDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, credentialsFile)
+	original := `{"claudeAiOauth":{"accessToken":"old-access","refreshToken":"old-refresh","expiresAt":1,"scopes":["user:inference"]},"other":true}`
+	if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultCCStatusConfig()
+	cfg.TokenEndpoint = server.URL
+
+	provider := &fileProvider{}
+	creds, err := provider.Credentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RefreshAndSave(cfg, creds, provider); err != nil {
+		t.Fatalf("expected refresh to succeed: %v", err)
+	}
+
+	saved, err := provider.Credentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.ClaudeAiOauth.AccessToken != "new-access" || saved.ClaudeAiOauth.RefreshToken != "new-refresh" {
+		t.Fatalf("expected rotated tokens to be written back, got %+v", saved.ClaudeAiOauth)
+	}
+	if !saved.ExpiresAt().After(time.Now()) {
+		t.Fatal("expected new expiry in the future")
+	}
+
+	var blob map[string]any
+	if err := json.Unmarshal(saved.raw, &blob); err != nil {
+		t.Fatal(err)
+	}
+	if blob["other"] != true {
+		t.Fatal("expected unknown top-level fields to be preserved")
+	}
+	if _, ok := blob["claudeAiOauth"].(map[string]any)["scopes"]; !ok {
+		t.Fatal("expected unknown oauth fields to be preserved")
+	}
+}
+
+func TestRefreshCredentialsRejectsErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	cfg := config.DefaultCCStatusConfig()
+	cfg.TokenEndpoint = server.URL
+
+	creds := newTokenCredentials("access")
+	creds.ClaudeAiOauth.RefreshToken = "refresh"
+
+	if err := RefreshCredentials(cfg, creds); err == nil {
+		t.Fatal("expected refresh to fail on error status")
+	}
+	if creds.ClaudeAiOauth.AccessToken != "access" {
+		t.Fatal("expected credentials to be unchanged after failed refresh")
+	}
+}
+
+func TestRefreshAndSaveRejectsReadOnlyProviders(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"access_token":"new-access","refresh_token":"new-refresh","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	cfg := config.DefaultCCStatusConfig()
+	cfg.TokenEndpoint = server.URL
+
+	creds := newTokenCredentials("access")
+	creds.ClaudeAiOauth.RefreshToken = "refresh"
+
+	provider := &commandProvider{command: "true"}
+	if err := RefreshAndSave(cfg, creds, provider); err == nil {
+		t.Fatal("expected read-only credentials to be rejected")
+	}
+	if requests != 0 {
+		t.Fatalf("expected the refresh token not to be redeemed, got %d requests", requests)
+	}
+	if creds.ClaudeAiOauth.RefreshToken != "refresh" {
+		t.Fatal("expected credentials to be unchanged")
+	}
+}
+
+// writeExpiringCredentials writes a credentials file whose token expires
+// within the refresh margin and returns a config limited to the file provider
+func writeExpiringCredentials(t *testing.T, tokenEndpoint string) *config.CCStatusConfig {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, config.ConfigDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	blob := fmt.Sprintf(`{"claudeAiOauth":{"accessToken":"old-access","refreshToken":"old-refresh","expiresAt":%d}}`,
+		time.Now().Add(time.Minute).UnixMilli())
+	if err := os.WriteFile(filepath.Join(dir, credentialsFile), []byte(blob), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultCCStatusConfig()
+	cfg.CredentialProviders = []string{ProviderFile}
+	cfg.TokenEndpoint = tokenEndpoint
+	return cfg
+}
+
+func TestGetAccessTokenBacksOffFailedRefresh(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant"}`))
+	}))
+	defer server.Close()
+
+	cfg := writeExpiringCredentials(t, server.URL)
+
+	for i := 0; i < 3; i++ {
+		token, err := GetAccessToken(cfg)
+		if err != nil || token != "old-access" {
+			t.Fatalf("expected the current token after a failed refresh, got %q, %v", token, err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("expected one refresh attempt during the backoff, got %d", got)
+	}
+
+	refreshErr, ok := LastRefreshError()
+	if !ok || refreshErr.Failures != 1 || !refreshErr.RetryAt.After(time.Now()) {
+		t.Fatalf("expected the failure to be recorded with a backoff deadline, got %+v", refreshErr)
+	}
+}
+
+func TestGetAccessTokenRefreshesOnceAcrossProcesses(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte(`{"access_token":"new-access","refresh_token":"new-refresh","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	cfg := writeExpiringCredentials(t, server.URL)
+
+	tokens := make(chan string, 4)
+	for i := 0; i < cap(tokens); i++ {
+		go func() {
+			token, _ := GetAccessToken(cfg)
+			tokens <- token
+		}()
+	}
+	for i := 0; i < cap(tokens); i++ {
+		if token := <-tokens; token != "new-access" {
+			t.Fatalf("expected every caller to get the refreshed token, got %q", token)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("expected the refresh token to be redeemed once, got %d", got)
+	}
+	if _, ok := LastRefreshError(); ok {
+		t.Fatal("expected no refresh error after a successful refresh")
+	}
+}
@@ -0,0 +1,121 @@
+package statusline
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+
+	"ccstatus/internal/api"
+	"ccstatus/internal/config"
+	"ccstatus/internal/filelock"
+)
+
+const (
+	// RefreshCommand is the hidden subcommand that runs the background refresher
+	RefreshCommand = "__refresh"
+	// ClaudeVersionFlag passes the Claude Code version to the refresher
+	ClaudeVersionFlag = "claude-version"
+
+	// refreshLockFile is held by the background refresher for its whole run,
+	// including the credential lookup before the fetch lock is taken
+	refreshLockFile = "ccstatus-refresh.lock"
+)
+
+// spawnRefresher starts the background refresher; tests replace it
+var spawnRefresher = startRefresherProcess
+
+// getRefreshLockPath returns the lock held by the running refresher
+func getRefreshLockPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, refreshLockFile), nil
+}
+
+// runFromCache renders immediately from the cache and never touches the
+// network. An expired cache starts a detached refresher so the next
+// render shows fresh data.
+func runFromCache(model, sessionID string, cfg *config.CCStatusConfig, claudeCodeVersion string) {
+	account := storedAccount()
+	if usage, fresh := loadCache(cfg, account); fresh {
+		printStatusLine(model, account, sessionID, usage, cfg)
+	} else {
+		if _, open := openCircuit(account); !open && !refresherRunning() {
+			_ = spawnRefresher(claudeCodeVersion)
+		}
+		if cached, ok := loadStaleCache(account); ok {
+			printStaleStatusLine(model, cached, cfg)
+		} else {
+			printFallback(model, cfg)
+		}
+	}
+
+	if fetchErr, ok := LastFetchError(account); ok {
+		printErrorIndicator(fetchErr.Kind)
+	}
+}
+
+// refresherRunning reports whether another process holds the refresh lock
+func refresherRunning() bool {
+	path, err := getRefreshLockPath()
+	if err != nil {
+		return false
+	}
+
+	lock, err := filelock.TryLock(path)
+	if err != nil {
+		return errors.Is(err, filelock.ErrLocked)
+	}
+	lock.Unlock()
+	return false
+}
+
+// startRefresherProcess re-executes ccstatus as a detached refresher in its
+// own session, so it outlives the statusline invocation
+func startRefresherProcess(claudeCodeVersion string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(exe, RefreshCommand, "--"+ClaudeVersionFlag, claudeCodeVersion)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// RunRefresher fetches usage into the cache. Only one refresher runs at a
+// time; others exit immediately without reading credentials or touching
+// the network.
+func RunRefresher(claudeCodeVersion string) error {
+	path, err := getRefreshLockPath()
+	if err != nil {
+		return err
+	}
+
+	lock, err := filelock.TryLock(path)
+	if err != nil {
+		if errors.Is(err, filelock.ErrLocked) {
+			return nil
+		}
+		return err
+	}
+	defer lock.Unlock()
+
+	cfg, _ := config.LoadCCStatusConfig()
+
+	token, err := GetAccessToken(cfg)
+	if err != nil {
+		authErr := &api.Error{Kind: api.KindAuth, Err: err}
+		recordFetchError(CurrentAccount(cfg), authErr)
+		return authErr
+	}
+
+	_, err = FetchUsage(cfg, token, claudeCodeVersion)
+	return err
+}
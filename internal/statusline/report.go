@@ -0,0 +1,78 @@
+package statusline
+
+import (
+	"time"
+
+	"ccstatus/internal/api"
+	"ccstatus/internal/config"
+	"ccstatus/internal/history"
+)
+
+// WindowReport describes one usage window in the status report
+type WindowReport struct {
+	Utilization float64
+	// ResetsAt is zero when the reset time is unknown
+	ResetsAt time.Time
+	// Estimated is set when the window reset since the usage was fetched
+	Estimated bool
+	// Pace is "ahead", "on" or "behind", or empty when it cannot be computed
+	Pace string
+}
+
+// Report is the usage of one account with the pace, projection and daily
+// budget derived from it, independent of which segments the statusline shows
+type Report struct {
+	Session WindowReport
+	Week    WindowReport
+	// Projection is nil until enough session history is recorded
+	Projection *history.Projection
+
+	// HasDailyBudget is false when the weekly reset time is unknown or no
+	// working day is left before it
+	HasDailyBudget  bool
+	DailyBudget     float64
+	WorkingDaysLeft float64
+	// NonWorkingDays are the configured days left out of the budget;
+	// InvalidDays are configured names that are not weekdays
+	NonWorkingDays []time.Weekday
+	InvalidDays    []string
+}
+
+// NewReport builds the report for usage of account at now
+func NewReport(cfg *config.CCStatusConfig, account string, usage *UsageResponse, now time.Time) *Report {
+	records := accountHistory(cfg, account, now.Add(-projectionLookback(cfg)))
+	projection := projectSession(cfg, records, usage, now)
+	usage, rolled := applyRollover(usage, now)
+
+	report := &Report{
+		Session:    windowReport(usage.FiveHour, rolled.session, sessionWindow, now),
+		Week:       windowReport(usage.SevenDay, rolled.week, weeklyWindow, now),
+		Projection: projection,
+	}
+	if rolled.session {
+		report.Projection = nil
+	}
+
+	nonWorking, invalid := config.ParseWeekdays(cfg.NonWorkingDays)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if nonWorking[d] {
+			report.NonWorkingDays = append(report.NonWorkingDays, d)
+		}
+	}
+	report.InvalidDays = invalid
+	report.DailyBudget, report.WorkingDaysLeft, report.HasDailyBudget = dailyBudget(usage.SevenDay, nonWorking, now)
+
+	return report
+}
+
+func windowReport(window api.UsageWindow, estimated bool, length time.Duration, now time.Time) WindowReport {
+	report := WindowReport{
+		Utilization: window.Utilization,
+		Estimated:   estimated,
+		Pace:        windowPace(window, length, now).String(),
+	}
+	if reset, ok := parseTimestamp(window.ResetsAt); ok {
+		report.ResetsAt = reset
+	}
+	return report
+}
@@ -0,0 +1,50 @@
+package statusline
+
+import "time"
+
+const (
+	// estimatedMarker prefixes values that are estimated rather than fetched
+	estimatedMarker = "~"
+
+	sessionWindow = 5 * time.Hour
+	weeklyWindow  = 7 * 24 * time.Hour
+)
+
+// rollover records which windows have reset since the usage was fetched
+type rollover struct {
+	session bool
+	week    bool
+}
+
+// applyRollover returns a copy of usage with windows whose reset time has
+// passed replaced by estimates: utilization drops to 0, the session reset
+// becomes unknown (the next window starts with the next message), and the
+// weekly reset moves forward by whole weeks.
+func applyRollover(usage *UsageResponse, now time.Time) (*UsageResponse, rollover) {
+	estimated := *usage
+	var rolled rollover
+
+	if reset, ok := parseTimestamp(usage.FiveHour.ResetsAt); ok && !reset.After(now) {
+		estimated.FiveHour.Utilization = 0
+		estimated.FiveHour.ResetsAt = ""
+		rolled.session = true
+	}
+
+	if reset, ok := parseTimestamp(usage.SevenDay.ResetsAt); ok && !reset.After(now) {
+		for !reset.After(now) {
+			reset = reset.Add(weeklyWindow)
+		}
+		estimated.SevenDay.Utilization = 0
+		estimated.SevenDay.ResetsAt = reset.Format(time.RFC3339)
+		rolled.week = true
+	}
+
+	return &estimated, rolled
+}
+
+// printEstimatedMarker prints the estimated marker before a rolled-over value
+func printEstimatedMarker(estimated bool) {
+	if estimated {
+		dimColor.Print(estimatedMarker)
+	}
+}
@@ -0,0 +1,73 @@
+package statusline
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ccstatus/internal/config"
+)
+
+func TestApplyRolloverLeavesCurrentWindowsAlone(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	usage := &UsageResponse{}
+	usage.FiveHour.Utilization = 42
+	usage.FiveHour.ResetsAt = now.Add(time.Hour).Format(time.RFC3339)
+	usage.SevenDay.Utilization = 17
+	usage.SevenDay.ResetsAt = now.Add(48 * time.Hour).Format(time.RFC3339)
+
+	got, rolled := applyRollover(usage, now)
+	if rolled.session || rolled.week {
+		t.Fatalf("expected no rollover, got %+v", rolled)
+	}
+	if *got != *usage {
+		t.Fatalf("expected usage unchanged, got %+v", got)
+	}
+}
+
+func TestApplyRolloverEstimatesResetWindows(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	weeklyReset := now.Add(-8 * 24 * time.Hour)
+	usage := &UsageResponse{}
+	usage.FiveHour.Utilization = 88
+	usage.FiveHour.ResetsAt = now.Add(-time.Minute).Format(time.RFC3339)
+	usage.SevenDay.Utilization = 64
+	usage.SevenDay.ResetsAt = weeklyReset.Format(time.RFC3339)
+
+	got, rolled := applyRollover(usage, now)
+	if !rolled.session || !rolled.week {
+		t.Fatalf("expected both windows rolled over, got %+v", rolled)
+	}
+	if got.FiveHour.Utilization != 0 || got.FiveHour.ResetsAt != "" {
+		t.Fatalf("expected empty session window, got %+v", got.FiveHour)
+	}
+	if got.SevenDay.Utilization != 0 {
+		t.Fatalf("expected weekly utilization 0, got %v", got.SevenDay.Utilization)
+	}
+	wantReset := weeklyReset.Add(14 * 24 * time.Hour).Format(time.RFC3339)
+	if got.SevenDay.ResetsAt != wantReset {
+		t.Fatalf("expected weekly reset %s, got %s", wantReset, got.SevenDay.ResetsAt)
+	}
+	if usage.FiveHour.Utilization != 88 {
+		t.Fatal("expected the original usage to be left untouched")
+	}
+}
+
+func TestPrintStatusLineMarksEstimatedWindows(t *testing.T) {
+	usage := &UsageResponse{}
+	usage.FiveHour.Utilization = 88
+	usage.FiveHour.ResetsAt = time.Now().Add(-time.Minute).Format(time.RFC3339)
+	usage.SevenDay.Utilization = 64
+	usage.SevenDay.ResetsAt = time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	cfg := config.DefaultCCStatusConfig()
+	cfg.ShowGitBranch = false
+
+	output := captureOutput(t, func() { printStatusLine("Opus", "", "", usage, cfg) })
+	if !strings.Contains(output, "Session: ~0% (resets --)") {
+		t.Fatalf("expected estimated session window, got %q", output)
+	}
+	if !strings.Contains(output, "Week: 64%") {
+		t.Fatalf("expected current weekly window, got %q", output)
+	}
+}
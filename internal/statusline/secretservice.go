@@ -0,0 +1,136 @@
+package statusline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	secretServiceName      = "org.freedesktop.secrets"
+	secretServicePath      = "/org/freedesktop/secrets"
+	secretServiceInterface = "org.freedesktop.Secret.Service"
+	secretItemInterface    = "org.freedesktop.Secret.Item"
+
+	// secretServiceTimeout bounds each D-Bus call so a stuck keyring
+	// daemon cannot freeze the statusline
+	secretServiceTimeout = 2 * time.Second
+)
+
+// secretServiceSecret mirrors the Secret Service (oayays) secret struct
+type secretServiceSecret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// secretServiceProvider looks up the credentials blob through the freedesktop
+// Secret Service API (GNOME Keyring, KWallet) on the session D-Bus
+type secretServiceProvider struct {
+	service string
+}
+
+func (p *secretServiceProvider) Name() string { return ProviderSecretService }
+
+func (p *secretServiceProvider) Credentials() (*Credentials, error) {
+	conn, err := connectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	item, session, err := p.findItem(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var secret secretServiceSecret
+	if err := callSecretService(conn.Object(secretServiceName, item), secretItemInterface+".GetSecret", session).Store(&secret); err != nil {
+		return nil, fmt.Errorf("cannot read secret: %w", err)
+	}
+
+	return parseCredentials(secret.Value)
+}
+
+// SaveCredentials replaces the secret of the existing item
+func (p *secretServiceProvider) SaveCredentials(creds *Credentials) error {
+	data, err := creds.marshal()
+	if err != nil {
+		return err
+	}
+
+	conn, err := connectSessionBus()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	item, session, err := p.findItem(conn)
+	if err != nil {
+		return err
+	}
+
+	secret := secretServiceSecret{
+		Session:     session,
+		Value:       data,
+		ContentType: "text/plain",
+	}
+	if err := callSecretService(conn.Object(secretServiceName, item), secretItemInterface+".SetSecret", secret).Err; err != nil {
+		return fmt.Errorf("cannot update secret: %w", err)
+	}
+	return nil
+}
+
+// findItem locates the unlocked credentials item and opens a plain-text
+// transfer session. Locked items are reported rather than unlocked, since
+// the statusline cannot answer an unlock prompt.
+func (p *secretServiceProvider) findItem(conn *dbus.Conn) (dbus.ObjectPath, dbus.ObjectPath, error) {
+	service := conn.Object(secretServiceName, secretServicePath)
+
+	var unlocked, locked []dbus.ObjectPath
+	attributes := map[string]string{"service": p.service}
+	if err := callSecretService(service, secretServiceInterface+".SearchItems", attributes).Store(&unlocked, &locked); err != nil {
+		return "", "", fmt.Errorf("cannot search secrets: %w", err)
+	}
+
+	if len(unlocked) == 0 {
+		if len(locked) > 0 {
+			return "", "", fmt.Errorf("%q is locked - unlock your keyring", p.service)
+		}
+		return "", "", fmt.Errorf("no %q item found", p.service)
+	}
+
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	if err := callSecretService(service, secretServiceInterface+".OpenSession", "plain", dbus.MakeVariant("")).Store(&output, &session); err != nil {
+		return "", "", fmt.Errorf("cannot open secret session: %w", err)
+	}
+
+	return unlocked[0], session, nil
+}
+
+// connectSessionBus connects to the session bus named in the environment.
+// It never autolaunches a bus, so machines without one fail fast.
+func connectSessionBus() (*dbus.Conn, error) {
+	address := os.Getenv("DBUS_SESSION_BUS_ADDRESS")
+	if address == "" {
+		return nil, fmt.Errorf("no session bus (DBUS_SESSION_BUS_ADDRESS is not set)")
+	}
+
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to session bus: %w", err)
+	}
+	return conn, nil
+}
+
+// callSecretService performs a D-Bus method call bounded by secretServiceTimeout
+func callSecretService(obj dbus.BusObject, method string, args ...any) *dbus.Call {
+	ctx, cancel := context.WithTimeout(context.Background(), secretServiceTimeout)
+	defer cancel()
+	return obj.CallWithContext(ctx, method, 0, args...)
+}
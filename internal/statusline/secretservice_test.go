@@ -0,0 +1,145 @@
+package statusline
+
+import (
+	"bufio"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const fakeItemPath = dbus.ObjectPath("/org/freedesktop/secrets/collection/login/1")
+
+// fakeSecretService implements the subset of the Secret Service API ccstatus uses
+type fakeSecretService struct {
+	service string
+	secret  []byte
+	locked  bool
+}
+
+func (s *fakeSecretService) SearchItems(attributes map[string]string) ([]dbus.ObjectPath, []dbus.ObjectPath, *dbus.Error) {
+	if attributes["service"] != s.service {
+		return []dbus.ObjectPath{}, []dbus.ObjectPath{}, nil
+	}
+	if s.locked {
+		return []dbus.ObjectPath{}, []dbus.ObjectPath{fakeItemPath}, nil
+	}
+	return []dbus.ObjectPath{fakeItemPath}, []dbus.ObjectPath{}, nil
+}
+
+func (s *fakeSecretService) OpenSession(algorithm string, input dbus.Variant) (dbus.Variant, dbus.ObjectPath, *dbus.Error) {
+	return dbus.MakeVariant(""), dbus.ObjectPath("/org/freedesktop/secrets/session/1"), nil
+}
+
+func (s *fakeSecretService) GetSecret(session dbus.ObjectPath) (secretServiceSecret, *dbus.Error) {
+	return secretServiceSecret{Session: session, Value: s.secret, ContentType: "text/plain"}, nil
+}
+
+func (s *fakeSecretService) SetSecret(secret secretServiceSecret) *dbus.Error {
+	s.secret = secret.Value
+	return nil
+}
+
+// startSessionBus launches a private dbus-daemon and points
+// DBUS_SESSION_BUS_ADDRESS at it for the duration of the test
+func startSessionBus(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("dbus-daemon"); err != nil {
+		t.Skip("dbus-daemon not available")
+	}
+
+	cmd := exec.Command("dbus-daemon", "--session", "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("cannot read bus address: %v", err)
+	}
+	address = strings.TrimSpace(address)
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", address)
+	return address
+}
+
+// serveFakeSecretService exports fake on the bus as org.freedesktop.secrets
+func serveFakeSecretService(t *testing.T, address string, fake *fakeSecretService) {
+	t.Helper()
+
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	if err := conn.Export(fake, secretServicePath, secretServiceInterface); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Export(fake, fakeItemPath, secretItemInterface); err != nil {
+		t.Fatal(err)
+	}
+
+	reply, err := conn.RequestName(secretServiceName, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("cannot own %s: %v", secretServiceName, err)
+	}
+}
+
+func TestSecretServiceProviderReadsAndWritesCredentials(t *testing.T) {
+	address := startSessionBus(t)
+	fake := &fakeSecretService{
+		service: keychainService,
+		secret:  []byte(`{"claudeAiOauth":{"accessToken":"sk-ant-oat01-dbus","refreshToken":"r"}}`),
+	}
+	serveFakeSecretService(t, address, fake)
+
+	provider := &secretServiceProvider{service: keychainService}
+	creds, err := provider.Credentials()
+	if err != nil {
+		t.Fatalf("expected credentials from secret service: %v", err)
+	}
+	if got := creds.ClaudeAiOauth.AccessToken; got != "sk-ant-oat01-dbus" {
+		t.Fatalf("expected secret service token, got %q", got)
+	}
+
+	creds.ClaudeAiOauth.AccessToken = "sk-ant-oat01-rotated"
+	if err := provider.SaveCredentials(creds); err != nil {
+		t.Fatalf("expected secret to be updated: %v", err)
+	}
+
+	creds, err = provider.Credentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := creds.ClaudeAiOauth.AccessToken; got != "sk-ant-oat01-rotated" {
+		t.Fatalf("expected rotated token, got %q", got)
+	}
+}
+
+func TestSecretServiceProviderReportsLockedItem(t *testing.T) {
+	address := startSessionBus(t)
+	serveFakeSecretService(t, address, &fakeSecretService{service: keychainService, locked: true})
+
+	_, err := (&secretServiceProvider{service: keychainService}).Credentials()
+	if err == nil || !strings.Contains(err.Error(), "locked") {
+		t.Fatalf("expected locked error, got %v", err)
+	}
+}
+
+func TestSecretServiceProviderFailsWithoutBus(t *testing.T) {
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "")
+
+	if _, err := (&secretServiceProvider{service: keychainService}).Credentials(); err == nil {
+		t.Fatal("expected error without a session bus")
+	}
+}
@@ -0,0 +1,183 @@
+package statusline
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ccstatus/internal/config"
+	"ccstatus/internal/filelock"
+)
+
+const (
+	sessionsFile     = "ccstatus-sessions.json"
+	sessionsLockFile = "ccstatus-sessions.lock"
+
+	// sessionsVersion is the current session ledger schema
+	sessionsVersion = 1
+	// sessionRetention drops sessions not seen for this long; resuming one
+	// later starts a new entry
+	sessionRetention = 7 * 24 * time.Hour
+	// sessionTouchInterval is how often an unchanged entry's last-seen time
+	// is written, so idle renders do not rewrite the ledger every time
+	sessionTouchInterval = time.Minute
+	// sessionsLockWait bounds how long a render waits for another window
+	// updating the ledger
+	sessionsLockWait = 200 * time.Millisecond
+)
+
+// sessionLedger is the session ledger file: one entry per Claude Code
+// session_id
+type sessionLedger struct {
+	Version  int                      `json:"version"`
+	Sessions map[string]*sessionEntry `json:"sessions"`
+}
+
+// sessionEntry is the usage one Claude Code session has consumed
+type sessionEntry struct {
+	// Account is the cache key of the account the session ran under
+	Account   string      `json:"account"`
+	FirstSeen time.Time   `json:"first_seen"`
+	LastSeen  time.Time   `json:"last_seen"`
+	FiveHour  windowDelta `json:"five_hour"`
+	SevenDay  windowDelta `json:"seven_day"`
+}
+
+// windowDelta tracks how much of one usage window a session consumed
+type windowDelta struct {
+	// Last is the utilization at the latest observation
+	Last float64 `json:"last"`
+	// Consumed is the total growth since the session was first seen
+	Consumed float64 `json:"consumed"`
+	// Delta is the growth at the latest change, i.e. since the previous
+	// prompt; it is cleared once usage stays unchanged for a while
+	Delta float64 `json:"delta"`
+	// ChangedAt is when Delta was last set
+	ChangedAt time.Time `json:"changed_at,omitempty"`
+}
+
+// observe records utilization and reports whether the entry changed. A drop
+// means the window reset, so all of the new value was used after the reset.
+// Usage only changes when it is fetched, so renders in between keep the
+// delta; it is cleared once hold has passed without a change.
+func (w *windowDelta) observe(utilization float64, now time.Time, hold time.Duration) bool {
+	if utilization == w.Last {
+		if w.Delta != 0 && now.Sub(w.ChangedAt) >= hold {
+			w.Delta = 0
+			return true
+		}
+		return false
+	}
+	growth := utilization - w.Last
+	if growth < 0 {
+		growth = utilization
+	}
+	w.Consumed += growth
+	w.Delta = growth
+	w.Last = utilization
+	w.ChangedAt = now
+	return true
+}
+
+// getSessionsPath returns the session ledger inside the active config directory
+func getSessionsPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, sessionsFile), nil
+}
+
+// readSessionLedger returns the ledger at path; a missing, unreadable or
+// outdated file yields an empty ledger
+func readSessionLedger(path string) *sessionLedger {
+	ledger := &sessionLedger{}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, ledger)
+	}
+	if ledger.Version != sessionsVersion || ledger.Sessions == nil {
+		ledger = &sessionLedger{Version: sessionsVersion, Sessions: make(map[string]*sessionEntry)}
+	}
+	return ledger
+}
+
+// recordSession observes fresh usage of account for a Claude Code session and
+// returns its updated ledger entry. The first observation is the baseline,
+// and a delta is shown until hold passes without new usage. The ledger is
+// best-effort: it returns nil when it cannot be updated.
+func recordSession(sessionID, account string, usage *UsageResponse, now time.Time, hold time.Duration) *sessionEntry {
+	if sessionID == "" {
+		return nil
+	}
+	path, err := getSessionsPath()
+	if err != nil {
+		return nil
+	}
+
+	lock, err := filelock.Acquire(filepath.Join(filepath.Dir(path), sessionsLockFile), sessionsLockWait)
+	if err != nil {
+		return nil
+	}
+	defer lock.Unlock()
+
+	ledger := readSessionLedger(path)
+	entry := ledger.Sessions[sessionID]
+	changed := true
+	if entry == nil || entry.Account != account {
+		// A new session, or the login changed: start from the current usage
+		entry = &sessionEntry{
+			Account:   account,
+			FirstSeen: now,
+			FiveHour:  windowDelta{Last: usage.FiveHour.Utilization},
+			SevenDay:  windowDelta{Last: usage.SevenDay.Utilization},
+		}
+		ledger.Sessions[sessionID] = entry
+	} else {
+		fiveHour := entry.FiveHour.observe(usage.FiveHour.Utilization, now, hold)
+		sevenDay := entry.SevenDay.observe(usage.SevenDay.Utilization, now, hold)
+		changed = fiveHour || sevenDay || now.Sub(entry.LastSeen) > sessionTouchInterval
+	}
+	if !changed {
+		return entry
+	}
+	entry.LastSeen = now
+
+	for id, e := range ledger.Sessions {
+		if now.Sub(e.LastSeen) > sessionRetention {
+			delete(ledger.Sessions, id)
+		}
+	}
+
+	data, err := json.Marshal(ledger)
+	if err != nil {
+		return entry
+	}
+	_ = config.WriteFileAtomic(path, data, 0600)
+	return entry
+}
+
+// printSessionDelta prints what the session consumed (e.g.
+// " | +14% this session (last +2%, week +3%)")
+func printSessionDelta(entry *sessionEntry, cfg *config.CCStatusConfig) {
+	sepColor.Print(" | ")
+	fmt.Printf("+%d%% this session", roundPct(entry.FiveHour.Consumed))
+
+	var details []string
+	if delta := roundPct(entry.FiveHour.Delta); delta > 0 {
+		details = append(details, fmt.Sprintf("last +%d%%", delta))
+	}
+	if week := roundPct(entry.SevenDay.Consumed); cfg.ShowWeeklyUsage && week > 0 {
+		details = append(details, fmt.Sprintf("week +%d%%", week))
+	}
+	if len(details) > 0 {
+		dimColor.Printf(" (%s)", strings.Join(details, ", "))
+	}
+}
+
+func roundPct(v float64) int {
+	return int(math.Round(v))
+}
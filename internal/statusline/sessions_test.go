@@ -0,0 +1,129 @@
+package statusline
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"ccstatus/internal/config"
+)
+
+func sessionUsage(fiveHour, sevenDay float64) *UsageResponse {
+	usage := &UsageResponse{}
+	usage.FiveHour.Utilization = fiveHour
+	usage.SevenDay.Utilization = sevenDay
+	return usage
+}
+
+func TestRecordSessionTracksConsumption(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	entry := recordSession("s1", "account", sessionUsage(30, 50), now, defaultTTL)
+	if entry == nil || entry.FiveHour.Consumed != 0 || entry.FiveHour.Last != 30 {
+		t.Fatalf("expected the first observation to be the baseline, got %+v", entry)
+	}
+
+	recordSession("s1", "account", sessionUsage(36, 51), now.Add(time.Minute), defaultTTL)
+	entry = recordSession("s1", "account", sessionUsage(44, 53), now.Add(2*time.Minute), defaultTTL)
+	if entry.FiveHour.Consumed != 14 || entry.FiveHour.Delta != 8 || entry.SevenDay.Consumed != 3 {
+		t.Fatalf("expected +14%% (last +8%%) and week +3%%, got %+v", entry)
+	}
+
+	// The session window reset at 44%; 5% was used since
+	entry = recordSession("s1", "account", sessionUsage(5, 54), now.Add(3*time.Minute), defaultTTL)
+	if entry.FiveHour.Consumed != 19 || entry.FiveHour.Delta != 5 {
+		t.Fatalf("expected consumption to continue across the reset, got %+v", entry.FiveHour)
+	}
+
+	// Renders without new usage keep the previous prompt's delta for a while
+	entry = recordSession("s1", "account", sessionUsage(5, 54), now.Add(4*time.Minute), defaultTTL)
+	if entry.FiveHour.Delta != 5 {
+		t.Fatalf("expected the delta to be kept, got %+v", entry.FiveHour)
+	}
+
+	// and clear it once usage stayed unchanged for the hold time
+	entry = recordSession("s1", "account", sessionUsage(5, 54), now.Add(3*time.Minute+defaultTTL), defaultTTL)
+	if entry.FiveHour.Delta != 0 || entry.FiveHour.Consumed != 19 || entry.SevenDay.Delta != 0 {
+		t.Fatalf("expected the delta to be cleared, got %+v", entry)
+	}
+	path, err := getSessionsPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored := readSessionLedger(path).Sessions["s1"]; stored.FiveHour.Delta != 0 {
+		t.Fatalf("expected the cleared delta to be saved, got %+v", stored.FiveHour)
+	}
+
+	// Sessions are independent, and switching accounts starts over
+	if other := recordSession("s2", "account", sessionUsage(5, 54), now, defaultTTL); other.FiveHour.Consumed != 0 {
+		t.Fatalf("expected a new session to start at zero, got %+v", other)
+	}
+	if switched := recordSession("s1", "other", sessionUsage(70, 10), now.Add(5*time.Minute), defaultTTL); switched.FiveHour.Consumed != 0 || switched.Account != "other" {
+		t.Fatalf("expected a new entry after switching accounts, got %+v", switched)
+	}
+
+	if entry := recordSession("", "account", sessionUsage(5, 54), now, defaultTTL); entry != nil {
+		t.Fatalf("expected no entry without a session id, got %+v", entry)
+	}
+}
+
+func TestRecordSessionExpiresOldSessions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	recordSession("old", "account", sessionUsage(10, 10), now.Add(-8*24*time.Hour), defaultTTL)
+	recordSession("recent", "account", sessionUsage(10, 10), now.Add(-time.Hour), defaultTTL)
+	recordSession("current", "account", sessionUsage(10, 10), now, defaultTTL)
+
+	path, err := getSessionsPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger := readSessionLedger(path)
+	if _, ok := ledger.Sessions["old"]; ok {
+		t.Fatal("expected the old session to expire")
+	}
+	if len(ledger.Sessions) != 2 {
+		t.Fatalf("expected two sessions, got %d", len(ledger.Sessions))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected ledger mode 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestPrintSessionDelta(t *testing.T) {
+	cfg := config.DefaultCCStatusConfig()
+	entry := &sessionEntry{
+		FiveHour: windowDelta{Consumed: 14, Delta: 2},
+		SevenDay: windowDelta{Consumed: 3},
+	}
+
+	if got := captureOutput(t, func() { printSessionDelta(entry, cfg) }); got != " | +14% this session (last +2%, week +3%)" {
+		t.Fatalf("unexpected session delta %q", got)
+	}
+
+	entry = &sessionEntry{}
+	if got := captureOutput(t, func() { printSessionDelta(entry, cfg) }); got != " | +0% this session" {
+		t.Fatalf("unexpected session delta for a new session %q", got)
+	}
+}
+
+func TestPrintStatusLineShowsSessionDelta(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := config.DefaultCCStatusConfig()
+	cfg.ShowResetTimes = false
+	cfg.ShowSessionDelta = true
+
+	captureOutput(t, func() { printStatusLine("Opus", "account", "s1", sessionUsage(30, 50), cfg) })
+	output := captureOutput(t, func() { printStatusLine("Opus", "account", "s1", sessionUsage(44, 52), cfg) })
+	if !strings.HasSuffix(output, "Week: 52% | +14% this session (last +14%, week +2%)") {
+		t.Fatalf("expected session delta at the end, got %q", output)
+	}
+}
@@ -0,0 +1,63 @@
+package statusline
+
+import (
+	"ccstatus/internal/config"
+	"ccstatus/internal/history"
+)
+
+// defaultSparklineLength is how many snapshots the sparkline shows when
+// sparkline_length is not set
+const defaultSparklineLength = 8
+
+// Sparkline glyphs from lowest to highest utilization. The ASCII ramp is for
+// terminals and fonts without block elements.
+var (
+	sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+	sparkASCII  = []rune("_.:-=+*#")
+)
+
+// sparklineLength returns the configured number of sparkline snapshots
+func sparklineLength(cfg *config.CCStatusConfig) int {
+	if cfg.SparklineLength > 0 {
+		return cfg.SparklineLength
+	}
+	return defaultSparklineLength
+}
+
+// sparklineValues returns the session utilization of the last n records
+func sparklineValues(records []history.Record, n int) []float64 {
+	if len(records) > n {
+		records = records[len(records)-n:]
+	}
+	values := make([]float64, len(records))
+	for i, rec := range records {
+		values[i] = rec.FiveHour.Utilization
+	}
+	return values
+}
+
+// sparkGlyph returns the glyph for utilization on the 0-100% scale, so bar
+// heights are comparable between renders
+func sparkGlyph(utilization float64, glyphs []rune) rune {
+	level := int(utilization / 100 * float64(len(glyphs)))
+	return glyphs[max(0, min(level, len(glyphs)-1))]
+}
+
+// printSparkline prints values as a sparkline segment (e.g. " ▁▂▃▅▇"), each
+// bar colored like its usage value. Fewer than two values show no trend and
+// print nothing.
+func printSparkline(values []float64, ascii bool) {
+	if len(values) < 2 {
+		return
+	}
+
+	glyphs := sparkBlocks
+	if ascii {
+		glyphs = sparkASCII
+	}
+
+	sepColor.Print(" ")
+	for _, v := range values {
+		getUsageColor(int(v)).Print(string(sparkGlyph(v, glyphs)))
+	}
+}
@@ -0,0 +1,59 @@
+package statusline
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ccstatus/internal/config"
+	"ccstatus/internal/history"
+)
+
+func TestSparklineValuesKeepsTheLastSnapshots(t *testing.T) {
+	start := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	var records []history.Record
+	for i, pct := range []float64{5, 10, 20, 40} {
+		usage := &UsageResponse{}
+		usage.FiveHour.Utilization = pct
+		records = append(records, history.NewRecord(start.Add(time.Duration(i)*time.Minute), "account", usage))
+	}
+
+	got := sparklineValues(records, 3)
+	if len(got) != 3 || got[0] != 10 || got[2] != 40 {
+		t.Fatalf("expected the last three values, got %v", got)
+	}
+	if got := sparklineValues(records, 10); len(got) != 4 {
+		t.Fatalf("expected every value when fewer than n, got %v", got)
+	}
+}
+
+func TestPrintSparkline(t *testing.T) {
+	values := []float64{0, 20, 45, 70, 100}
+
+	if got := captureOutput(t, func() { printSparkline(values, false) }); got != " ▁▂▄▆█" {
+		t.Fatalf("unexpected block sparkline %q", got)
+	}
+	if got := captureOutput(t, func() { printSparkline(values, true) }); got != " _.-+#" {
+		t.Fatalf("unexpected ASCII sparkline %q", got)
+	}
+	if got := captureOutput(t, func() { printSparkline(values[:1], false) }); got != "" {
+		t.Fatalf("expected no sparkline for a single value, got %q", got)
+	}
+}
+
+func TestRenderStatusLineShowsSparkline(t *testing.T) {
+	usage := &UsageResponse{}
+	usage.FiveHour.Utilization = 40
+	usage.FiveHour.ResetsAt = time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	cfg := config.DefaultCCStatusConfig()
+	cfg.ShowWeeklyUsage = false
+	cfg.SparklineASCII = true
+
+	output := captureOutput(t, func() {
+		renderStatusLine("Opus", usage, cfg, false, &sessionTrend{sparkline: []float64{10, 25, 40}})
+	})
+	if !strings.HasSuffix(output, ") _:-") {
+		t.Fatalf("expected sparkline after the session segment, got %q", output)
+	}
+}
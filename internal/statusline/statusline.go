@@ -2,16 +2,19 @@
 package statusline
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"strings"
 	"time"
 
+	"ccstatus/internal/api"
 	"ccstatus/internal/config"
+	"ccstatus/internal/filelock"
 	"ccstatus/internal/ui"
 
 	"github.com/fatih/color"
@@ -23,29 +26,12 @@ type Input struct {
 		DisplayName string `json:"display_name"`
 	} `json:"model"`
 	Version string `json:"version"`
-}
-
-// Credentials represents the OAuth credentials from Keychain
-type Credentials struct {
-	ClaudeAiOauth struct {
-		AccessToken string `json:"accessToken"`
-	} `json:"claudeAiOauth"`
+	// SessionID identifies the Claude Code session across renders
+	SessionID string `json:"session_id"`
 }
 
 // UsageResponse represents the API response from Anthropic
-type UsageResponse struct {
-	FiveHour struct {
-		Utilization float64 `json:"utilization"`
-		ResetsAt    string  `json:"resets_at"`
-	} `json:"five_hour"`
-	SevenDay struct {
-		Utilization float64 `json:"utilization"`
-		ResetsAt    string  `json:"resets_at"`
-	} `json:"seven_day"`
-	Error *struct {
-		Message string `json:"message"`
-	} `json:"error,omitempty"`
-}
+type UsageResponse = api.UsageResponse
 
 // Run executes the statusline logic and prints output to stdout.
 func Run() {
@@ -62,26 +48,49 @@ func Run() {
 		model = "Unknown"
 	}
 
-	// Get OAuth token from macOS Keychain
-	token, err := GetAccessToken()
-	if err != nil || token == "" {
+	// A running daemon already holds the token and the latest usage
+	if snapshot, ok := queryDaemon(); ok {
+		printSnapshot(model, input.SessionID, cfg, snapshot)
+		return
+	}
+
+	// In background mode, render from cache and let a detached process refresh it
+	if cfg.BackgroundRefresh {
+		runFromCache(model, input.SessionID, cfg, input.Version)
+		return
+	}
+
+	runDirect(model, input.SessionID, cfg, input.Version)
+}
+
+// runDirect fetches usage itself, or reuses the cache while it is fresh,
+// and renders the statusline
+func runDirect(model, sessionID string, cfg *config.CCStatusConfig, claudeCodeVersion string) {
+	// Get OAuth token from the configured credential providers
+	token, err := GetAccessToken(cfg)
+	if err != nil {
 		printFallback(model, cfg)
+		printErrorIndicator(api.KindAuth)
 		return
 	}
 
 	// Fetch usage data from Anthropic API
-	usage, err := FetchUsage(token, input.Version)
-	if err != nil || usage == nil || usage.Error != nil {
-		if staleUsage, ok := loadStaleCache(); ok {
-			printStatusLine(model, staleUsage, cfg)
-			return
+	usage, err := FetchUsage(cfg, token, claudeCodeVersion)
+	if err != nil {
+		if cached, ok := loadStaleCache(accountKey(token)); ok {
+			printStaleStatusLine(model, cached, cfg)
+		} else {
+			printFallback(model, cfg)
+		}
+		// Another process is fetching: the cache is only stale, nothing failed
+		if !errors.Is(err, errFetchBusy) {
+			printErrorIndicator(api.KindOf(err))
 		}
-		printFallback(model, cfg)
 		return
 	}
 
 	// Format and print statusline
-	printStatusLine(model, usage, cfg)
+	printStatusLine(model, accountKey(token), sessionID, usage, cfg)
 }
 
 // readInputFromStdin reads and parses the JSON input from stdin.
@@ -98,80 +107,81 @@ func readInputFromStdin() Input {
 	return input
 }
 
-// GetAccessToken retrieves the OAuth token from macOS Keychain
-func GetAccessToken() (string, error) {
-	cmd := exec.Command("security", "find-generic-password", "-s", "Claude Code-credentials", "-w")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
+// errFetchBusy is returned, wrapped, when another process held the fetch
+// lock for the whole wait
+var errFetchBusy = errors.New("another process is fetching usage")
 
-	credsJSON := strings.TrimSpace(string(output))
-	if credsJSON == "" {
-		return "", fmt.Errorf("empty credentials")
-	}
-
-	var creds Credentials
-	if err := json.Unmarshal([]byte(credsJSON), &creds); err != nil {
-		return "", err
-	}
+// FetchUsage retrieves usage data from the Anthropic API.
+// Cached usage and errors are kept per account, so switching logins never
+// shows the previous account's values.
+func FetchUsage(cfg *config.CCStatusConfig, token, claudeCodeVersion string) (*UsageResponse, error) {
+	return fetchUsage(cfg, token, claudeCodeVersion, false)
+}
 
-	return creds.ClaudeAiOauth.AccessToken, nil
+// RefreshUsage fetches usage even when the cache is fresh or a previous
+// failure is backing off, and stores the result in the cache. It fails
+// rather than return cached usage when another process keeps fetching.
+func RefreshUsage(cfg *config.CCStatusConfig, token, claudeCodeVersion string) (*UsageResponse, error) {
+	return fetchUsage(cfg, token, claudeCodeVersion, true)
 }
 
-// FetchUsage retrieves usage data from the Anthropic API.
-func FetchUsage(token, claudeCodeVersion string) (*UsageResponse, error) {
-	if usage, ok := loadCache(); ok {
-		return usage, nil
-	}
+func fetchUsage(cfg *config.CCStatusConfig, token, claudeCodeVersion string, force bool) (*UsageResponse, error) {
+	account := accountKey(token)
+	if !force {
+		if usage, ok := loadCache(cfg, account); ok {
+			return usage, nil
+		}
 
-	req, err := http.NewRequest("GET", "https://api.anthropic.com/api/oauth/usage", nil)
-	if err != nil {
-		return nil, err
+		// Stop hitting the endpoint while a previous failure is backing off
+		if fetchErr, open := openCircuit(account); open {
+			return nil, fetchErr.backoffError()
+		}
 	}
 
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("anthropic-beta", "oauth-2025-04-20")
-	req.Header.Set("User-Agent", claudeCodeUserAgent(claudeCodeVersion))
+	// Only one process fetches at a time; the others wait briefly for its result.
+	// If the lock itself is unusable, fetch anyway rather than show nothing.
+	lockPath, _ := getFetchLockPath()
+	lock, err := filelock.Acquire(lockPath, fetchWait)
+	if errors.Is(err, filelock.ErrLocked) {
+		// Callers fall back to the stale cache; a forced refresh fails outright
+		if force {
+			return nil, fmt.Errorf("%w; try again shortly", errFetchBusy)
+		}
+		return nil, &api.Error{Kind: api.KindTimeout, Err: errFetchBusy}
+	}
+	defer lock.Unlock()
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+	// The previous holder may have fetched, or failed, while we waited
+	if !force {
+		if usage, ok := loadCache(cfg, account); ok {
+			return usage, nil
+		}
+		if fetchErr, open := openCircuit(account); open {
+			return nil, fetchErr.backoffError()
+		}
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	client, err := api.NewFromConfig(cfg, claudeCodeVersion)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
-
-	var usage UsageResponse
-	if err := json.Unmarshal(body, &usage); err != nil {
+	usage, err := client.FetchUsage(context.Background(), token)
+	if err != nil {
+		recordFetchError(account, err)
 		return nil, err
 	}
 
-	saveCache(&usage)
+	saveCache(account, usage)
+	recordHistory(cfg, account, usage)
 
-	return &usage, nil
+	return usage, nil
 }
 
-func claudeCodeUserAgent(version string) string {
-	if version == "" {
-		return "claude-code"
-	}
-	return "claude-code/" + version
-}
-
-// formatResetTime converts an ISO timestamp to local 12-hour format (e.g., "3:45pm")
-func formatResetTime(isoTime string) string {
+// parseTimestamp parses an API timestamp, with or without fractional seconds
+func parseTimestamp(isoTime string) (time.Time, bool) {
 	if isoTime == "" {
-		return "--"
+		return time.Time{}, false
 	}
 
 	t, err := time.Parse(time.RFC3339, isoTime)
@@ -179,10 +189,23 @@ func formatResetTime(isoTime string) string {
 		// Try parsing with fractional seconds
 		t, err = time.Parse("2006-01-02T15:04:05.999999999Z07:00", isoTime)
 		if err != nil {
-			return "--"
+			return time.Time{}, false
 		}
 	}
+	return t, true
+}
 
+// formatResetTime converts an ISO timestamp to local 12-hour format (e.g., "3:45pm")
+func formatResetTime(isoTime string) string {
+	t, ok := parseTimestamp(isoTime)
+	if !ok {
+		return "--"
+	}
+	return formatClock(t)
+}
+
+// formatClock formats t in local 12-hour format (e.g., "3:45pm")
+func formatClock(t time.Time) string {
 	// Convert to local timezone
 	local := t.Local()
 
@@ -205,19 +228,11 @@ func formatResetTime(isoTime string) string {
 
 // formatWeeklyResetTime converts an ISO timestamp to local format with date (e.g., "Jan 15 3:45pm")
 func formatWeeklyResetTime(isoTime string) string {
-	if isoTime == "" {
+	t, ok := parseTimestamp(isoTime)
+	if !ok {
 		return "--"
 	}
 
-	t, err := time.Parse(time.RFC3339, isoTime)
-	if err != nil {
-		// Try parsing with fractional seconds
-		t, err = time.Parse("2006-01-02T15:04:05.999999999Z07:00", isoTime)
-		if err != nil {
-			return "--"
-		}
-	}
-
 	// Convert to local timezone
 	local := t.Local()
 
@@ -259,6 +274,7 @@ var (
 	greenColor  = color.New(color.FgGreen)
 	yellowColor = color.New(color.FgYellow)
 	redColor    = color.New(color.FgRed)
+	staleColor  = color.New(color.Faint, color.Italic)
 )
 
 // getUsageColor returns the appropriate color based on usage percentage
@@ -272,6 +288,32 @@ func getUsageColor(pct int) *color.Color {
 	return greenColor
 }
 
+// errorLabels are the compact statusline indicators for each error kind
+var errorLabels = map[api.ErrorKind]string{
+	api.KindAuth:        "auth",
+	api.KindRateLimited: "rate-limited",
+	api.KindServer:      "server",
+	api.KindOffline:     "offline",
+	api.KindTimeout:     "timeout",
+	api.KindMalformed:   "bad response",
+	api.KindAPI:         "api error",
+	api.KindUnexpected:  "error",
+}
+
+// ErrorLabel returns the compact label shown for an error kind
+func ErrorLabel(kind api.ErrorKind) string {
+	if label, ok := errorLabels[kind]; ok {
+		return label
+	}
+	return errorLabels[api.KindUnexpected]
+}
+
+// printErrorIndicator prints a compact warning (e.g. "⚠ offline") after the usage segments
+func printErrorIndicator(kind api.ErrorKind) {
+	sepColor.Print(" | ")
+	yellowColor.Printf("%s %s", ui.IconWarning, ErrorLabel(kind))
+}
+
 // printFallback prints the statusline with placeholder values
 func printFallback(model string, cfg *config.CCStatusConfig) {
 	modelColor.Print(model)
@@ -294,8 +336,50 @@ func printFallback(model string, cfg *config.CCStatusConfig) {
 	}
 }
 
-// printStatusLine formats and prints the full statusline
-func printStatusLine(model string, usage *UsageResponse, cfg *config.CCStatusConfig) {
+// printStatusLine formats and prints the full statusline with fresh usage
+// of account, including the trend from the usage history and session ledger
+func printStatusLine(model, account, sessionID string, usage *UsageResponse, cfg *config.CCStatusConfig) {
+	renderStatusLine(model, usage, cfg, false, loadSessionTrend(cfg, account, sessionID, usage, time.Now()))
+}
+
+// printStaleStatusLine prints cached usage that could not be refreshed, dimmed
+// and followed by its age. Values older than max_stale are replaced by placeholders.
+func printStaleStatusLine(model string, cached *cachedUsage, cfg *config.CCStatusConfig) {
+	age := time.Since(cached.FetchedAt)
+	if age > config.ParseDuration(cfg.MaxStale, defaultMaxStale) {
+		printFallback(model, cfg)
+		return
+	}
+
+	renderStatusLine(model, &cached.Usage, cfg, true, nil)
+
+	if cfg.ShowDataAge && (cfg.ShowSessionUsage || cfg.ShowWeeklyUsage) {
+		dimColor.Printf(" · %s", formatAge(age))
+	}
+}
+
+// formatAge formats how long ago data was fetched (e.g. "12m ago")
+func formatAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(age.Hours()/24))
+	}
+}
+
+// renderStatusLine prints the statusline; stale values are drawn in staleColor
+// instead of their usage color. trend, if any, follows the session usage,
+// and the session ledger ends the line.
+func renderStatusLine(model string, usage *UsageResponse, cfg *config.CCStatusConfig, stale bool, trend *sessionTrend) {
+	// Windows whose reset time has passed are shown as estimates until fresh data arrives
+	now := time.Now()
+	usage, rolled := applyRollover(usage, now)
+
 	modelColor.Print(model)
 
 	// Git branch
@@ -310,15 +394,28 @@ func printStatusLine(model string, usage *UsageResponse, cfg *config.CCStatusCon
 	if cfg.ShowSessionUsage {
 		sepColor.Print(" | ")
 		sessionPct := int(usage.FiveHour.Utilization)
-		usageColor := getUsageColor(sessionPct)
+		usageColor, sessionPace := windowColor(cfg, usage.FiveHour, sessionWindow, now)
+		if stale {
+			usageColor, sessionPace = staleColor, paceUnknown
+		}
 		if cfg.ShowResetTimes {
 			sessionReset := formatResetTime(usage.FiveHour.ResetsAt)
 			fmt.Print("Session: ")
+			printEstimatedMarker(rolled.session)
 			usageColor.Printf("%d%%", sessionPct)
+			printPaceMarker(sessionPace, usageColor)
 			dimColor.Printf(" (resets %s)", sessionReset)
 		} else {
 			fmt.Print("Session: ")
+			printEstimatedMarker(rolled.session)
 			usageColor.Printf("%d%%", sessionPct)
+			printPaceMarker(sessionPace, usageColor)
+		}
+		if trend != nil {
+			if trend.projection != nil && !rolled.session {
+				printProjection(trend.projection)
+			}
+			printSparkline(trend.sparkline, cfg.SparklineASCII)
 		}
 	}
 
@@ -326,15 +423,35 @@ func printStatusLine(model string, usage *UsageResponse, cfg *config.CCStatusCon
 	if cfg.ShowWeeklyUsage {
 		sepColor.Print(" | ")
 		weeklyPct := int(usage.SevenDay.Utilization)
-		usageColor := getUsageColor(weeklyPct)
+		usageColor, weeklyPace := windowColor(cfg, usage.SevenDay, weeklyWindow, now)
+		if stale {
+			usageColor, weeklyPace = staleColor, paceUnknown
+		}
+		budget := weeklyBudgetLabel(cfg, usage.SevenDay, now)
 		if cfg.ShowResetTimes {
 			weeklyReset := formatWeeklyResetTime(usage.SevenDay.ResetsAt)
 			fmt.Print("Week: ")
+			printEstimatedMarker(rolled.week)
 			usageColor.Printf("%d%%", weeklyPct)
-			dimColor.Printf(" (resets %s)", weeklyReset)
+			printPaceMarker(weeklyPace, usageColor)
+			if budget != "" {
+				dimColor.Printf(" (%s, resets %s)", budget, weeklyReset)
+			} else {
+				dimColor.Printf(" (resets %s)", weeklyReset)
+			}
 		} else {
 			fmt.Print("Week: ")
+			printEstimatedMarker(rolled.week)
 			usageColor.Printf("%d%%", weeklyPct)
+			printPaceMarker(weeklyPace, usageColor)
+			if budget != "" {
+				dimColor.Printf(" (%s)", budget)
+			}
 		}
 	}
+
+	// Usage consumed by this Claude Code session
+	if trend != nil && trend.ledger != nil {
+		printSessionDelta(trend.ledger, cfg)
+	}
 }
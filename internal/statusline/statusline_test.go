@@ -1,31 +1,52 @@
 package statusline
 
 import (
+	"bytes"
 	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"ccstatus/internal/api"
 	"ccstatus/internal/config"
+	"ccstatus/internal/filelock"
+	"ccstatus/internal/history"
+
+	"github.com/fatih/color"
 )
 
-func TestClaudeCodeUserAgent(t *testing.T) {
-	tests := []struct {
-		name    string
-		version string
-		want    string
-	}{
-		{name: "with version", version: "1.0.80", want: "claude-code/1.0.80"},
-		{name: "without version", version: "", want: "claude-code"},
+func TestFetchUsageUsesConfiguredBaseURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != api.UsagePath {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		w.Write([]byte(`{"five_hour":{"utilization":12},"seven_day":{"utilization":34}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(api.BaseURLEnv, server.URL)
+
+	usage, err := FetchUsage(config.DefaultCCStatusConfig(), "token", "1.0.0")
+	if err != nil {
+		t.Fatalf("expected usage from mock server: %v", err)
+	}
+	if usage.FiveHour.Utilization != 12 || usage.SevenDay.Utilization != 34 {
+		t.Fatalf("unexpected usage %+v", usage)
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if got := claudeCodeUserAgent(tt.version); got != tt.want {
-				t.Fatalf("expected %q, got %q", tt.want, got)
-			}
-		})
+	store, err := history.Open(config.DefaultCCStatusConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, _, err := store.Records()
+	if err != nil || len(records) != 1 || records[0].Account != accountKey("token") {
+		t.Fatalf("expected the fetch to be added to history, got %+v (%v)", records, err)
 	}
 }
 
@@ -36,14 +57,14 @@ func TestSaveCacheCreatesClaudeDir(t *testing.T) {
 	usage := &UsageResponse{}
 	usage.FiveHour.Utilization = 42
 
-	saveCache(usage)
+	saveCache(accountKey("token"), usage)
 
 	path := filepath.Join(home, config.ConfigDir, cacheFile)
 	if _, err := os.Stat(path); err != nil {
 		t.Fatalf("expected cache file to be written: %v", err)
 	}
 
-	cached, ok := loadCache()
+	cached, ok := loadCache(config.DefaultCCStatusConfig(), accountKey("token"))
 	if !ok {
 		t.Fatal("expected fresh cache to load")
 	}
@@ -67,7 +88,8 @@ func TestLoadCacheRejectsExpiredCacheButAllowsStaleFallback(t *testing.T) {
 	}
 	cached.Usage.SevenDay.Utilization = 73
 
-	data, err := json.Marshal(cached)
+	store := cacheStore{Version: cacheVersion, Accounts: map[string]*cachedUsage{accountKey("token"): &cached}}
+	data, err := json.Marshal(store)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -77,15 +99,356 @@ func TestLoadCacheRejectsExpiredCacheButAllowsStaleFallback(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if _, ok := loadCache(); ok {
+	if _, ok := loadCache(config.DefaultCCStatusConfig(), accountKey("token")); ok {
 		t.Fatal("expected expired cache to be rejected")
 	}
 
-	stale, ok := loadStaleCache()
+	stale, ok := loadStaleCache(accountKey("token"))
 	if !ok {
 		t.Fatal("expected stale cache fallback to load")
 	}
-	if got := stale.SevenDay.Utilization; got != 73 {
+	if got := stale.Usage.SevenDay.Utilization; got != 73 {
 		t.Fatalf("expected stale utilization 73, got %v", got)
 	}
 }
+
+func TestCacheIsSeparatePerProfile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	t.Setenv(config.ConfigDirEnv, filepath.Join(home, "work"))
+	work := &UsageResponse{}
+	work.FiveHour.Utilization = 80
+	saveCache(accountKey("token"), work)
+
+	t.Setenv(config.ConfigDirEnv, filepath.Join(home, "personal"))
+	if _, ok := loadStaleCache(accountKey("token")); ok {
+		t.Fatal("expected personal profile not to see work profile cache")
+	}
+
+	t.Setenv(config.ConfigDirEnv, filepath.Join(home, "work"))
+	cached, ok := loadCache(config.DefaultCCStatusConfig(), accountKey("token"))
+	if !ok {
+		t.Fatal("expected work profile cache to load")
+	}
+	if got := cached.FiveHour.Utilization; got != 80 {
+		t.Fatalf("expected cached utilization 80, got %v", got)
+	}
+}
+
+// captureOutput collects everything the statusline prints during fn,
+// whether written through fmt or the color package
+func captureOutput(t *testing.T, fn func()) string {
+	t.Helper()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldStdout, oldOutput, oldNoColor := os.Stdout, color.Output, color.NoColor
+	os.Stdout, color.Output, color.NoColor = writer, writer, true
+	defer func() {
+		os.Stdout, color.Output, color.NoColor = oldStdout, oldOutput, oldNoColor
+	}()
+
+	done := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, reader)
+		done <- buf.String()
+	}()
+
+	fn()
+	writer.Close()
+	return <-done
+}
+
+func TestPrintErrorIndicatorShowsCompactLabel(t *testing.T) {
+	tests := []struct {
+		kind api.ErrorKind
+		want string
+	}{
+		{kind: api.KindAuth, want: "⚠ auth"},
+		{kind: api.KindRateLimited, want: "⚠ rate-limited"},
+		{kind: api.KindOffline, want: "⚠ offline"},
+		{kind: api.ErrorKind("new-kind"), want: "⚠ error"},
+	}
+
+	for _, tt := range tests {
+		got := captureOutput(t, func() { printErrorIndicator(tt.kind) })
+		if !strings.Contains(got, tt.want) {
+			t.Fatalf("expected %q in indicator, got %q", tt.want, got)
+		}
+	}
+}
+
+func TestFetchUsageRecordsLastErrorAndKeepsStaleUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(api.BaseURLEnv, server.URL)
+
+	// Seed an expired cache entry so FetchUsage goes to the network
+	cached := cachedUsage{FetchedAt: time.Now().Add(-defaultTTL - time.Minute)}
+	cached.Usage.FiveHour.Utilization = 55
+	writeCache(accountKey("token"), &cached)
+
+	if _, err := FetchUsage(config.DefaultCCStatusConfig(), "token", ""); api.KindOf(err) != api.KindRateLimited {
+		t.Fatalf("expected rate-limited error, got %v", err)
+	}
+
+	fetchErr, ok := LastFetchError(accountKey("token"))
+	if !ok || fetchErr.Kind != api.KindRateLimited {
+		t.Fatalf("expected recorded rate-limited error, got %+v", fetchErr)
+	}
+
+	stale, ok := loadStaleCache(accountKey("token"))
+	if !ok || stale.Usage.FiveHour.Utilization != 55 {
+		t.Fatal("expected stale usage to survive a recorded error")
+	}
+
+	saveCache(accountKey("token"), &stale.Usage)
+	if _, ok := LastFetchError(accountKey("token")); ok {
+		t.Fatal("expected successful save to clear the last error")
+	}
+}
+
+func TestBackoffFor(t *testing.T) {
+	serverErr := &api.Error{Kind: api.KindServer, StatusCode: 503}
+
+	tests := []struct {
+		name     string
+		err      error
+		failures int
+		want     time.Duration
+	}{
+		{name: "first failure", err: serverErr, failures: 1, want: minBackoff},
+		{name: "third failure doubles twice", err: serverErr, failures: 3, want: 4 * minBackoff},
+		{name: "capped", err: serverErr, failures: 20, want: maxBackoff},
+		{name: "retry-after wins", err: &api.Error{Kind: api.KindRateLimited, RetryAfter: 2 * time.Minute}, failures: 5, want: 2 * time.Minute},
+		{name: "retry-after capped", err: &api.Error{Kind: api.KindRateLimited, RetryAfter: 3 * time.Hour}, failures: 1, want: maxRetryAfter},
+		{name: "auth not backed off", err: &api.Error{Kind: api.KindAuth, StatusCode: 401}, failures: 4, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backoffFor(tt.err, tt.failures); got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFetchUsageStopsCallingAPIWhileBackingOff(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"five_hour":{"utilization":10},"seven_day":{"utilization":20}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(api.BaseURLEnv, server.URL)
+	cfg := config.DefaultCCStatusConfig()
+
+	if _, err := FetchUsage(cfg, "token", ""); api.KindOf(err) != api.KindRateLimited {
+		t.Fatalf("expected rate-limited error, got %v", err)
+	}
+
+	fetchErr, ok := LastFetchError(accountKey("token"))
+	if !ok {
+		t.Fatal("expected failure to be recorded")
+	}
+	if wait := time.Until(fetchErr.RetryAt); wait < 110*time.Second || wait > 120*time.Second {
+		t.Fatalf("expected Retry-After to set a ~120s deadline, got %v", wait)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := FetchUsage(cfg, "token", ""); api.KindOf(err) != api.KindRateLimited {
+			t.Fatalf("expected open circuit to report rate-limited, got %v", err)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("expected no requests while backing off, got %d", requests)
+	}
+
+	// Move the deadline into the past: the next call probes the API again
+	cached, _ := readCache(accountKey("token"))
+	cached.LastError.RetryAt = time.Now().Add(-time.Second)
+	writeCache(accountKey("token"), cached)
+
+	usage, err := FetchUsage(cfg, "token", "")
+	if err != nil {
+		t.Fatalf("expected fetch after deadline to succeed: %v", err)
+	}
+	if usage.FiveHour.Utilization != 10 || requests != 2 {
+		t.Fatalf("expected one new request returning fresh usage, got %d requests", requests)
+	}
+	if _, ok := LastFetchError(accountKey("token")); ok {
+		t.Fatal("expected success to close the circuit")
+	}
+}
+
+func TestRunFromCacheSpawnsRefresherOnlyWhenExpired(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeClaudeAccount(t, home, "account-1")
+
+	spawned := 0
+	oldSpawn := spawnRefresher
+	spawnRefresher = func(string) error {
+		spawned++
+		return nil
+	}
+	t.Cleanup(func() { spawnRefresher = oldSpawn })
+
+	cfg := config.DefaultCCStatusConfig()
+
+	out := captureOutput(t, func() { runFromCache("Opus", "", cfg, "") })
+	if spawned != 1 {
+		t.Fatalf("expected refresher for empty cache, got %d spawns", spawned)
+	}
+	if !strings.Contains(out, "Session: --%") {
+		t.Fatalf("expected placeholders without cache, got %q", out)
+	}
+
+	cached := cachedUsage{FetchedAt: time.Now().Add(-defaultTTL - time.Minute)}
+	cached.Usage.FiveHour.Utilization = 61
+	writeCache(CurrentAccount(cfg), &cached)
+
+	out = captureOutput(t, func() { runFromCache("Opus", "", cfg, "") })
+	if spawned != 2 {
+		t.Fatalf("expected refresher for expired cache, got %d spawns", spawned)
+	}
+	if !strings.Contains(out, "Session: 61%") {
+		t.Fatalf("expected stale value rendered immediately, got %q", out)
+	}
+
+	saveCache(CurrentAccount(cfg), &cached.Usage)
+	captureOutput(t, func() { runFromCache("Opus", "", cfg, "") })
+	if spawned != 2 {
+		t.Fatalf("expected no refresher for fresh cache, got %d spawns", spawned)
+	}
+}
+
+func TestRunFromCacheSkipsSpawnWhileRefresherHoldsLock(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	spawned := 0
+	oldSpawn := spawnRefresher
+	spawnRefresher = func(string) error {
+		spawned++
+		return nil
+	}
+	t.Cleanup(func() { spawnRefresher = oldSpawn })
+
+	path, err := getRefreshLockPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock, err := filelock.TryLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Unlock()
+
+	captureOutput(t, func() { runFromCache("Opus", "", config.DefaultCCStatusConfig(), "") })
+	if spawned != 0 {
+		t.Fatalf("expected no duplicate refresher, got %d spawns", spawned)
+	}
+
+	// A refresher started anyway must exit without fetching
+	if err := RunRefresher(""); err != nil {
+		t.Fatalf("expected losing refresher to exit quietly: %v", err)
+	}
+}
+
+func TestPrintStaleStatusLineShowsAge(t *testing.T) {
+	cached := &cachedUsage{FetchedAt: time.Now().Add(-12*time.Minute - time.Second)}
+	cached.Usage.FiveHour.Utilization = 42
+	cached.Usage.FiveHour.ResetsAt = time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	cfg := config.DefaultCCStatusConfig()
+	cfg.ShowResetTimes = false
+	cfg.ShowWeeklyUsage = false
+	cfg.ShowDataAge = true
+
+	output := captureOutput(t, func() { printStaleStatusLine("Opus", cached, cfg) })
+	if !strings.HasSuffix(output, "Session: 42% · 12m ago") {
+		t.Fatalf("expected stale usage with its age, got %q", output)
+	}
+
+	cfg.ShowDataAge = false
+	output = captureOutput(t, func() { printStaleStatusLine("Opus", cached, cfg) })
+	if strings.Contains(output, "ago") {
+		t.Fatalf("expected no age segment when disabled, got %q", output)
+	}
+}
+
+func TestPrintStaleStatusLineHidesValuesPastMaxStale(t *testing.T) {
+	cached := &cachedUsage{FetchedAt: time.Now().Add(-2 * time.Hour)}
+	cached.Usage.FiveHour.Utilization = 42
+
+	cfg := config.DefaultCCStatusConfig()
+	cfg.MaxStale = "1h"
+
+	output := captureOutput(t, func() { printStaleStatusLine("Opus", cached, cfg) })
+	if !strings.Contains(output, "Session: --%") || strings.Contains(output, "42%") {
+		t.Fatalf("expected placeholders past max_stale, got %q", output)
+	}
+}
+
+func TestRunDirectTreatsCacheAsStaleWhileFetchLockIsHeld(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(OAuthTokenEnv, "token")
+	cfg := config.DefaultCCStatusConfig()
+	cfg.CredentialProviders = []string{ProviderEnv}
+	cfg.MaxStale = "1h"
+
+	cached := cachedUsage{FetchedAt: time.Now().Add(-6 * 24 * time.Hour)}
+	cached.Usage.FiveHour.Utilization = 95
+	writeCache(accountKey("token"), &cached)
+
+	path, err := getFetchLockPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock, err := filelock.TryLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Unlock()
+
+	output := captureOutput(t, func() { runDirect("Opus", "", cfg, "") })
+	if !strings.Contains(output, "Session: --%") || strings.Contains(output, "95%") {
+		t.Fatalf("expected placeholders for a cache past max_stale, got %q", output)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		age  time.Duration
+		want string
+	}{
+		{age: 20 * time.Second, want: "just now"},
+		{age: 12 * time.Minute, want: "12m ago"},
+		{age: 3*time.Hour + 10*time.Minute, want: "3h ago"},
+		{age: 50 * time.Hour, want: "2d ago"},
+	}
+
+	for _, tt := range tests {
+		if got := formatAge(tt.age); got != tt.want {
+			t.Fatalf("formatAge(%v) = %q, want %q", tt.age, got, tt.want)
+		}
+	}
+}
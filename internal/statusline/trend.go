@@ -0,0 +1,45 @@
+package statusline
+
+import (
+	"time"
+
+	"ccstatus/internal/config"
+	"ccstatus/internal/history"
+)
+
+// sessionTrend is what the locally recorded usage adds to a fresh render
+type sessionTrend struct {
+	// projection is nil until enough recent history is recorded
+	projection *history.Projection
+	// sparkline holds the most recent session utilization values, oldest first
+	sparkline []float64
+	// ledger is the Claude Code session's consumption, nil when unknown
+	ledger *sessionEntry
+}
+
+// loadSessionTrend reads the account's recent history once for the enabled
+// trend segments and records usage in the session ledger. It returns nil
+// when they are all off.
+func loadSessionTrend(cfg *config.CCStatusConfig, account, sessionID string, usage *UsageResponse, now time.Time) *sessionTrend {
+	if !cfg.ShowProjection && !cfg.ShowSparkline && !cfg.ShowSessionDelta {
+		return nil
+	}
+
+	trend := &sessionTrend{}
+	if cfg.ShowProjection || cfg.ShowSparkline {
+		since := now.Add(-max(projectionLookback(cfg), sessionWindow))
+		records := accountHistory(cfg, account, since)
+
+		if cfg.ShowProjection {
+			trend.projection = projectSession(cfg, records, usage, now)
+		}
+		if cfg.ShowSparkline {
+			trend.sparkline = sparklineValues(records, sparklineLength(cfg))
+		}
+	}
+	if cfg.ShowSessionDelta {
+		// The delta lasts one cache TTL, about the time between fetches
+		trend.ledger = recordSession(sessionID, account, usage, now, config.ParseDuration(cfg.CacheTTL, defaultTTL))
+	}
+	return trend
+}
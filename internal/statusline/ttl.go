@@ -0,0 +1,51 @@
+package statusline
+
+import (
+	"time"
+
+	"ccstatus/internal/config"
+)
+
+const (
+	// Bounds for the adaptive TTL
+	minTTL = 30 * time.Second
+	maxTTL = 30 * time.Minute
+
+	// resetSlack delays the first fetch after a window resets, giving the
+	// API a moment to report the new window
+	resetSlack = 30 * time.Second
+)
+
+// cacheTTL returns how long usage fetched at fetchedAt stays fresh.
+// Without adaptive_ttl this is the configured cache_ttl. With it, the TTL
+// shrinks as utilization nears the limit, grows while the session is idle,
+// and never extends past the next window reset.
+func cacheTTL(cfg *config.CCStatusConfig, usage *UsageResponse, fetchedAt time.Time) time.Duration {
+	base := config.ParseDuration(cfg.CacheTTL, defaultTTL)
+	if !cfg.AdaptiveTTL || usage == nil {
+		return base
+	}
+
+	ttl := base
+	peak := max(usage.FiveHour.Utilization, usage.SevenDay.Utilization)
+	switch {
+	case peak >= 90:
+		ttl = base / 5
+	case peak >= 70:
+		ttl = base / 2
+	case usage.FiveHour.Utilization == 0:
+		ttl = base * 3
+	}
+
+	for _, resetsAt := range []string{usage.FiveHour.ResetsAt, usage.SevenDay.ResetsAt} {
+		reset, ok := parseTimestamp(resetsAt)
+		if !ok || !reset.After(fetchedAt) {
+			continue
+		}
+		if untilReset := reset.Sub(fetchedAt) + resetSlack; untilReset < ttl {
+			ttl = untilReset
+		}
+	}
+
+	return min(max(ttl, minTTL), max(maxTTL, base))
+}
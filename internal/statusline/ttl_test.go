@@ -0,0 +1,70 @@
+package statusline
+
+import (
+	"testing"
+	"time"
+
+	"ccstatus/internal/config"
+)
+
+func TestCacheTTLUsesConfiguredValue(t *testing.T) {
+	cfg := config.DefaultCCStatusConfig()
+	usage := &UsageResponse{}
+	now := time.Now()
+
+	if got := cacheTTL(cfg, usage, now); got != defaultTTL {
+		t.Fatalf("expected default TTL, got %v", got)
+	}
+
+	cfg.CacheTTL = "2m"
+	if got := cacheTTL(cfg, usage, now); got != 2*time.Minute {
+		t.Fatalf("expected configured TTL, got %v", got)
+	}
+
+	cfg.CacheTTL = "soon"
+	if got := cacheTTL(cfg, usage, now); got != defaultTTL {
+		t.Fatalf("expected invalid TTL to fall back to default, got %v", got)
+	}
+}
+
+func TestCacheTTLAdaptsToUtilizationAndResets(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	cfg := config.DefaultCCStatusConfig()
+	cfg.AdaptiveTTL = true
+
+	usageAt := func(session, week float64, sessionReset time.Duration) *UsageResponse {
+		usage := &UsageResponse{}
+		usage.FiveHour.Utilization = session
+		usage.SevenDay.Utilization = week
+		if sessionReset != 0 {
+			usage.FiveHour.ResetsAt = now.Add(sessionReset).Format(time.RFC3339)
+		}
+		return usage
+	}
+
+	tests := []struct {
+		name  string
+		usage *UsageResponse
+		want  time.Duration
+	}{
+		{name: "moderate usage keeps base", usage: usageAt(30, 20, 4*time.Hour), want: defaultTTL},
+		{name: "high usage halves", usage: usageAt(75, 20, 4*time.Hour), want: defaultTTL / 2},
+		{name: "near limit shortens", usage: usageAt(92, 20, 4*time.Hour), want: time.Minute},
+		{name: "weekly near limit shortens", usage: usageAt(10, 95, 4*time.Hour), want: time.Minute},
+		{name: "idle lengthens", usage: usageAt(0, 20, 0), want: 15 * time.Minute},
+		{name: "approaching reset", usage: usageAt(30, 20, 2*time.Minute), want: 2*time.Minute + resetSlack},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cacheTTL(cfg, tt.usage, now); got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	cfg.CacheTTL = "1m"
+	if got := cacheTTL(cfg, usageAt(95, 20, 4*time.Hour), now); got != minTTL {
+		t.Fatalf("expected TTL floor %v, got %v", minTTL, got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"text/template"
 	"time"
+	"unicode/utf8"
 
 	"github.com/briandowns/spinner"
 	"github.com/chzyer/readline"
@@ -206,6 +207,57 @@ func CodeBlock(content string) {
 	Dim.Println("  └" + strings.Repeat("─", boxWidth+2) + "┘")
 }
 
+// Table prints rows in aligned columns under a bold header. Cells are
+// padded by display width, so multi-byte characters line up.
+func Table(headers []string, rows [][]string) {
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i < len(widths) {
+				widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+			}
+		}
+	}
+
+	pad := func(cell string, width int) string {
+		return cell + strings.Repeat(" ", width-utf8.RuneCountInString(cell))
+	}
+
+	fmt.Print("  ")
+	for i, h := range headers {
+		Bold.Print(pad(h, widths[i]))
+		if i < len(headers)-1 {
+			fmt.Print("  ")
+		}
+	}
+	fmt.Println()
+
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+	Dim.Println("  " + strings.Repeat("─", total+2*(len(widths)-1)))
+
+	for _, row := range rows {
+		fmt.Print("  ")
+		for i := range headers {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			if i == len(headers)-1 {
+				fmt.Print(cell)
+			} else {
+				fmt.Print(pad(cell, widths[i]) + "  ")
+			}
+		}
+		fmt.Println()
+	}
+}
+
 // Divider prints a horizontal divider
 func Divider() {
 	Dim.Println("  " + strings.Repeat("─", 50))